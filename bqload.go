@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//loadRows writes a slice of row structs to a table with a load job instead of streaming inserts. Loaded rows can be
+//deleted or replaced by the next run straight away, and a WriteTruncate load replaces the whole table at once, which
+//streaming into a freshly recreated table can't do reliably
+func loadRows(ctx context.Context, table *bigquery.Table, schema bigquery.Schema, rows interface{}, disposition bigquery.TableWriteDisposition) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	slice := reflect.ValueOf(rows)
+	for i := 0; i < slice.Len(); i++ {
+		if err := encodeRow(encoder, schema, slice.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return loadReader(ctx, table, schema, &buf, disposition)
+}
+
+//encodeRow writes a row struct as a line of newline delimited JSON, the format loadReader loads
+func encodeRow(encoder *json.Encoder, schema bigquery.Schema, row interface{}) error {
+	saver := &bigquery.StructSaver{Schema: schema, Struct: row}
+	values, _, err := saver.Save()
+	if err != nil {
+		return err
+	}
+	return encoder.Encode(values)
+}
+
+//loadReader runs a load job over rows encoded by encodeRow, for row sets too large to hold in memory
+func loadReader(ctx context.Context, table *bigquery.Table, schema bigquery.Schema, rows io.Reader, disposition bigquery.TableWriteDisposition) error {
+	source := bigquery.NewReaderSource(rows)
+	source.SourceFormat = bigquery.JSON
+	source.Schema = schema
+	loader := table.LoaderFrom(source)
+	loader.CreateDisposition = bigquery.CreateIfNeeded
+	loader.WriteDisposition = disposition
+	job, err := loader.Run(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
+
+//runStatement runs a DML or DDL statement and waits for it to finish
+func runStatement(ctx context.Context, q *bigquery.Query) error {
+	job, err := q.Run(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//CalculationArc links a concept to the total it adds into (or is subtracted from, when Weight is negative)
+type CalculationArc struct {
+	Parent string
+	Weight float64
+}
+
+//CalculationLinkbase holds the calculation relationships of a filing by extended link role, then child concept
+type CalculationLinkbase map[string]map[string]CalculationArc
+
+type calculationLinkbaseXML struct {
+	CalculationLinks []struct {
+		Role     string `xml:"role,attr"`
+		Locators []struct {
+			Href  string `xml:"href,attr"`
+			Label string `xml:"label,attr"`
+		} `xml:"loc"`
+		Arcs []struct {
+			From   string `xml:"from,attr"`
+			To     string `xml:"to,attr"`
+			Weight string `xml:"weight,attr"`
+		} `xml:"calculationArc"`
+	} `xml:"calculationLink"`
+}
+
+//CalculationLinkbaseFile returns the calculation linkbase (e.g. aapl-20191228_cal.xml) among a filing's input files
+func CalculationLinkbaseFile(filingSummaryObject FilingSummary) (string, bool) {
+	for _, file := range filingSummaryObject.InputFiles.File {
+		file = strings.TrimSpace(file)
+		if strings.HasSuffix(strings.ToLower(file), "_cal.xml") {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+//ParseCalculationLinkbase reads the summation-item arcs of a calculation linkbase. Locators point to concepts by
+//their schema href (us-gaap-2019-01-31.xsd#us-gaap_GrossProfit), the fragment is turned into a QName
+func ParseCalculationLinkbase(linkbase []byte) CalculationLinkbase {
+	var parsed calculationLinkbaseXML
+	calculations := make(CalculationLinkbase)
+	if err := xml.Unmarshal(linkbase, &parsed); err != nil {
+		return calculations
+	}
+	for _, link := range parsed.CalculationLinks {
+		concepts := make(map[string]string)
+		for _, locator := range link.Locators {
+			concepts[locator.Label] = strings.Replace(locator.Href[strings.Index(locator.Href, "#")+1:], "_", ":", 1)
+		}
+		if calculations[link.Role] == nil {
+			calculations[link.Role] = make(map[string]CalculationArc)
+		}
+		for _, arc := range link.Arcs {
+			weight, err := strconv.ParseFloat(arc.Weight, 64)
+			if err != nil {
+				weight = 1
+			}
+			calculations[link.Role][concepts[arc.To]] = CalculationArc{Parent: concepts[arc.From], Weight: weight}
+		}
+	}
+	return calculations
+}
+
+//Parent returns the total a concept rolls up into on a statement, looking through the other roles of the
+//filing when the statement's own role doesn't place the concept
+func (calculations CalculationLinkbase) Parent(role string, concept string) (CalculationArc, bool) {
+	if arc, ok := calculations[role][concept]; ok {
+		return arc, true
+	}
+	roles := make([]string, 0, len(calculations))
+	for other := range calculations {
+		roles = append(roles, other)
+	}
+	sort.Strings(roles)
+	for _, other := range roles {
+		if arc, ok := calculations[other][concept]; ok {
+			return arc, true
+		}
+	}
+	return CalculationArc{}, false
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+)
+
+//Categories a report in the filing summary can be filed under
+const (
+	ReportCover         = "Cover"
+	ReportStatement     = "Statement"
+	ReportNote          = "Note"
+	ReportPolicy        = "Policy"
+	ReportTable         = "Table"
+	ReportDetails       = "Details"
+	ReportUncategorized = "Uncategorized"
+)
+
+//requiredStatements are the statements every 10-Q and 10-K is expected to have, the ones that
+//are not found are recorded per filing
+var requiredStatements = []string{StatementBalanceSheet, StatementIncomeStatement, StatementCashFlowStatement}
+
+//optionalStatements are recorded when a filing has them. Many 10-Qs have no equity statement or combine comprehensive
+//income with the income statement, so their absence isn't a gap
+var optionalStatements = []string{StatementStockholdersEquity, StatementComprehensiveIncome}
+
+//CatalogReport is a report of the filing summary with its category and URL
+type CatalogReport struct {
+	Category   string
+	Name       string
+	ShortName  string
+	Role       string
+	ParentRole string
+	Position   string
+	URL        string
+}
+
+//ReportCatalog lists every report of a filing along with the statement candidates picked out of them
+type ReportCatalog struct {
+	FilingURL  string
+	Reports    []CatalogReport
+	Statements StatementCandidates
+	fallbacks  map[string]string
+}
+
+//FilingStatementItem records whether a statement was found in a filing and which report it came from
+type FilingStatementItem struct {
+	Year            string
+	Quarter         string
+	CIK             string
+	FilingURL       string
+	Statement       string
+	Required        bool
+	Found           bool
+	ReportName      string
+	Role            string
+	URL             string
+	Confidence      float64
+	AccessionNumber string
+}
+
+var menuCategories = map[string]string{
+	"Cover":      ReportCover,
+	"Statements": ReportStatement,
+	"Notes":      ReportNote,
+	"Policies":   ReportPolicy,
+	"Tables":     ReportTable,
+	"Details":    ReportDetails,
+}
+
+//reportCategory uses MenuCategory when the filing summary has it and falls back to the conventions of the
+//LongName ("0000002 - Statement - ...", "Debt (Tables)") for older filing summaries that don't
+func reportCategory(report FilingSummaryReport) string {
+	if category, ok := menuCategories[report.MenuCategory]; ok {
+		return category
+	}
+	longName := strings.ToLower(report.LongName)
+	switch {
+	case report.ReportType == "Book" || report.HtmlFileName == "":
+		return ReportUncategorized
+	case strings.Contains(longName, "- document -"):
+		return ReportCover
+	case strings.Contains(longName, "- statement -"):
+		return ReportStatement
+	case strings.Contains(longName, "(policies)"):
+		return ReportPolicy
+	case strings.Contains(longName, "(tables)"):
+		return ReportTable
+	case strings.Contains(longName, "(details"):
+		return ReportDetails
+	case strings.Contains(longName, "- disclosure -"):
+		return ReportNote
+	}
+	return ReportUncategorized
+}
+
+//NewReportCatalog categorizes every report of a filing summary and classifies its statements with the rules
+func NewReportCatalog(filingSummaryObject FilingSummary, filingDirectoryIndexURL string, rules StatementRules) ReportCatalog {
+	catalog := ReportCatalog{FilingURL: filingDirectoryIndexURL, Statements: ClassifyStatements(filingSummaryObject, filingDirectoryIndexURL, rules), fallbacks: make(map[string]string)}
+	for _, rule := range rules.Rules {
+		if rule.Fallback != "" {
+			catalog.fallbacks[rule.Statement] = rule.Fallback
+		}
+	}
+	for _, report := range filingSummaryObject.MyReports.Report {
+		catalogReport := CatalogReport{Category: reportCategory(report), Name: report.LongName, ShortName: report.ShortName, Role: report.Role, ParentRole: report.ParentRole, Position: report.Position}
+		if report.HtmlFileName != "" {
+			catalogReport.URL = filingDirectoryIndexURL + "/" + report.HtmlFileName
+		}
+		catalog.Reports = append(catalog.Reports, catalogReport)
+	}
+	return catalog
+}
+
+//Category returns the reports filed under a category in filing summary order
+func (c ReportCatalog) Category(category string) []CatalogReport {
+	var reports []CatalogReport
+	for _, report := range c.Reports {
+		if report.Category == category {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+//Statement returns the best report for a statement, or that of the rule's fallback statement, ok is false when the
+//filing has neither. Filers whose only statement of operations is a "Statements of Comprehensive Loss" get that report
+//as their income statement through the bundled rules
+func (c ReportCatalog) Statement(statement string) (StatementCandidate, bool) {
+	if candidate, ok := c.Statements.Best(statement); ok || c.fallbacks[statement] == "" {
+		return candidate, ok
+	}
+	return c.Statements.Best(c.fallbacks[statement])
+}
+
+//StatementCoverage lists each required statement and whether it was found, for recording which filings lack which
+//statements, along with the optional statements the filing has
+func (c ReportCatalog) StatementCoverage(year string, qtr string, cik string, accessionNumber string) []FilingStatementItem {
+	var coverage []FilingStatementItem
+	for _, statement := range requiredStatements {
+		coverage = append(coverage, c.coverageItem(year, qtr, cik, accessionNumber, statement, true))
+	}
+	for _, statement := range optionalStatements {
+		if item := c.coverageItem(year, qtr, cik, accessionNumber, statement, false); item.Found {
+			coverage = append(coverage, item)
+		}
+	}
+	return coverage
+}
+
+func (c ReportCatalog) coverageItem(year string, qtr string, cik string, accessionNumber string, statement string, required bool) FilingStatementItem {
+	item := FilingStatementItem{Year: year, Quarter: qtr, CIK: cik, FilingURL: c.FilingURL, Statement: statement, Required: required, AccessionNumber: accessionNumber}
+	if candidate, ok := c.Statement(statement); ok {
+		item.Found = true
+		item.ReportName = candidate.Report.LongName
+		item.Role = candidate.Report.Role
+		item.URL = candidate.URL
+		item.Confidence = candidate.Confidence
+	}
+	return item
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestReportCatalogFallback(t *testing.T) {
+	catalog := NewReportCatalog(parseTestFilingSummary(t, comprehensiveLossFilingSummary), testFilingURL, DefaultStatementRules())
+	candidate, ok := catalog.Statement(StatementIncomeStatement)
+	if !ok || candidate.Report.HtmlFileName != "R4.htm" {
+		t.Errorf("income statement = %+v %v, want the statement of comprehensive loss", candidate, ok)
+	}
+	if _, ok := catalog.Statement(StatementStockholdersEquity); ok {
+		t.Error("found a stockholders' equity statement, the filing has none and the rule has no fallback")
+	}
+
+	catalog = NewReportCatalog(parseTestFilingSummary(t, appleFilingSummary), testFilingURL, DefaultStatementRules())
+	if candidate, ok := catalog.Statement(StatementIncomeStatement); !ok || candidate.Report.HtmlFileName != "R2.htm" {
+		t.Errorf("income statement = %+v %v, want the statements of operations over the fallback", candidate, ok)
+	}
+}
+
+func TestStatementCoverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		want    map[string]bool
+	}{
+		{
+			name:    "a 10-K with every statement",
+			summary: appleFilingSummary,
+			want:    map[string]bool{StatementBalanceSheet: true, StatementIncomeStatement: true, StatementCashFlowStatement: true, StatementStockholdersEquity: true, StatementComprehensiveIncome: true},
+		},
+		{
+			name:    "no equity statement isn't a gap",
+			summary: comprehensiveLossFilingSummary,
+			want:    map[string]bool{StatementBalanceSheet: true, StatementIncomeStatement: true, StatementCashFlowStatement: true, StatementComprehensiveIncome: true},
+		},
+		{
+			name:    "missing primary statements are recorded",
+			summary: `<FilingSummary><MyReports><Report><HtmlFileName>R2.htm</HtmlFileName><LongName>0002 - Statement - Consolidated Balance Sheets</LongName><MenuCategory>Statements</MenuCategory></Report></MyReports></FilingSummary>`,
+			want:    map[string]bool{StatementBalanceSheet: true, StatementIncomeStatement: false, StatementCashFlowStatement: false},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			catalog := NewReportCatalog(parseTestFilingSummary(t, test.summary), testFilingURL, DefaultStatementRules())
+			coverage := catalog.StatementCoverage("2019", "QTR4", "320193", "0000320193-20-000010")
+			if len(coverage) != len(test.want) {
+				t.Fatalf("got %d statements %+v, want %d", len(coverage), coverage, len(test.want))
+			}
+			for _, item := range coverage {
+				found, ok := test.want[item.Statement]
+				if !ok || item.Found != found {
+					t.Errorf("%s Found = %v, want %v", item.Statement, item.Found, found)
+				}
+				if required := item.Statement == StatementBalanceSheet || item.Statement == StatementIncomeStatement || item.Statement == StatementCashFlowStatement; item.Required != required {
+					t.Errorf("%s Required = %v, want %v", item.Statement, item.Required, required)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Statements ParseFilingSummary can recognize, matching the statement names used in the rules file
+const (
+	StatementBalanceSheet        = "BalanceSheet"
+	StatementIncomeStatement     = "IncomeStatement"
+	StatementComprehensiveIncome = "ComprehensiveIncome"
+	StatementCashFlowStatement   = "CashFlowStatement"
+	StatementStockholdersEquity  = "StockholdersEquity"
+	StatementParenthetical       = "Parenthetical"
+)
+
+//Weights of each piece of evidence that a report is a given statement, they add up to a confidence of 1
+const (
+	nameMatchConfidence     = 0.5
+	roleMatchConfidence     = 0.3
+	statementMenuConfidence = 0.2
+)
+
+//go:embed statement_rules.json
+var defaultStatementRules []byte
+
+//StatementRule describes how to recognize one kind of statement from the reports listed in a filing summary.
+//Patterns are matched against the lowercase LongName and ShortName, RolePatterns against the lowercase Role URI,
+//and a report containing any of the Exclusions is never matched. A filing with no report for the statement uses
+//the report of the Fallback statement instead, if any
+type StatementRule struct {
+	Statement    string   `json:"statement"`
+	Patterns     []string `json:"patterns"`
+	RolePatterns []string `json:"rolePatterns"`
+	Exclusions   []string `json:"exclusions"`
+	Priority     int      `json:"priority"`
+	Fallback     string   `json:"fallback,omitempty"`
+}
+
+type StatementRules struct {
+	Rules []StatementRule `json:"rules"`
+}
+
+//StatementCandidate is a report from the filing summary that a rule matched, with how confident the match is
+type StatementCandidate struct {
+	Statement  string
+	Report     FilingSummaryReport
+	URL        string
+	Confidence float64
+	Priority   int
+}
+
+type StatementCandidates []StatementCandidate
+
+//DefaultStatementRules returns the rules shipped with the ETL in statement_rules.json
+func DefaultStatementRules() StatementRules {
+	var rules StatementRules
+	if err := json.Unmarshal(defaultStatementRules, &rules); err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+//LoadStatementRules reads a rules file in the same format as statement_rules.json
+func LoadStatementRules(path string) (StatementRules, error) {
+	var rules StatementRules
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	err = json.Unmarshal(file, &rules)
+	return rules, err
+}
+
+func containsAny(s string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(s, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+//isStatementReport tells whether a report was filed as a statement. Newer filing summaries say so in MenuCategory,
+//older ones only in the LongName ("00000002 - Statement - Consolidated Balance Sheets")
+func isStatementReport(report FilingSummaryReport) bool {
+	if report.MenuCategory != "" {
+		return report.MenuCategory == "Statements"
+	}
+	return strings.Contains(strings.ToLower(report.LongName), "- statement -")
+}
+
+//matchStatementRule scores a report against a rule, returning 0 when the rule doesn't apply
+func matchStatementRule(report FilingSummaryReport, rule StatementRule) float64 {
+	names := strings.ToLower(report.LongName + " " + report.ShortName)
+	role := strings.ToLower(report.Role)
+	if report.ReportType == "Book" || containsAny(names, rule.Exclusions) || containsAny(role, rule.Exclusions) {
+		return 0
+	}
+	//notes, policies and details reuse statement names ("Stockholders' Equity (Details)"), so they are never candidates
+	if report.MenuCategory != "" && report.MenuCategory != "Statements" {
+		return 0
+	}
+	confidence := 0.0
+	if containsAny(names, rule.Patterns) {
+		confidence += nameMatchConfidence
+	}
+	if containsAny(role, rule.RolePatterns) {
+		confidence += roleMatchConfidence
+	}
+	if confidence == 0 {
+		return 0
+	}
+	if isStatementReport(report) {
+		confidence += statementMenuConfidence
+	}
+	return confidence
+}
+
+//ClassifyStatements matches every report of a filing summary against the rules and returns all candidates,
+//best first within each statement by confidence, then rule priority, then position in the filing
+func ClassifyStatements(filingSummaryObject FilingSummary, filingDirectoryIndexURL string, rules StatementRules) StatementCandidates {
+	var candidates StatementCandidates
+	for _, report := range filingSummaryObject.MyReports.Report {
+		matched := make(map[string]int)
+		for _, rule := range rules.Rules {
+			confidence := matchStatementRule(report, rule)
+			if confidence == 0 {
+				continue
+			}
+			candidate := StatementCandidate{Statement: rule.Statement, Report: report, URL: filingDirectoryIndexURL + "/" + report.HtmlFileName, Confidence: confidence, Priority: rule.Priority}
+			//several rules for the same statement can match a report, keep the best one
+			if i, ok := matched[rule.Statement]; ok {
+				if candidates[i].Confidence < confidence || (candidates[i].Confidence == confidence && candidates[i].Priority < rule.Priority) {
+					candidates[i] = candidate
+				}
+				continue
+			}
+			matched[rule.Statement] = len(candidates)
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Statement != candidates[j].Statement {
+			return candidates[i].Statement < candidates[j].Statement
+		}
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		if candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority > candidates[j].Priority
+		}
+		return reportPosition(candidates[i].Report) < reportPosition(candidates[j].Report)
+	})
+	return candidates
+}
+
+func reportPosition(report FilingSummaryReport) int {
+	position, err := strconv.Atoi(report.Position)
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return position
+}
+
+//All returns the candidates for a statement, best first
+func (candidates StatementCandidates) All(statement string) StatementCandidates {
+	var matches StatementCandidates
+	for _, candidate := range candidates {
+		if candidate.Statement == statement {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+//Best returns the most likely report for a statement
+func (candidates StatementCandidates) Best(statement string) (StatementCandidate, bool) {
+	matches := candidates.All(statement)
+	if len(matches) == 0 {
+		return StatementCandidate{}, false
+	}
+	return matches[0], true
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+//appleFilingSummary is an excerpt of the FilingSummary.xml of Apple's 2019 10-K, which files its reports under menu categories
+const appleFilingSummary = `<?xml version="1.0" encoding="utf-8"?>
+<FilingSummary>
+  <Version>3.19.2</Version>
+  <ReportFormat>Html</ReportFormat>
+  <MyReports>
+    <Report instance="aapl-20190928.xml">
+      <IsDefault>false</IsDefault>
+      <HasEmbeddedReports>false</HasEmbeddedReports>
+      <HtmlFileName>R1.htm</HtmlFileName>
+      <LongName>0000001 - Document - Document and Entity Information</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/DocumentAndEntityInformation</Role>
+      <ShortName>Document and Entity Information</ShortName>
+      <MenuCategory>Cover</MenuCategory>
+      <Position>1</Position>
+    </Report>
+    <Report instance="aapl-20190928.xml">
+      <HtmlFileName>R2.htm</HtmlFileName>
+      <LongName>0000002 - Statement - CONSOLIDATED STATEMENTS OF OPERATIONS</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/CONSOLIDATEDSTATEMENTSOFOPERATIONS</Role>
+      <ShortName>CONSOLIDATED STATEMENTS OF OPERATIONS</ShortName>
+      <MenuCategory>Statements</MenuCategory>
+      <Position>2</Position>
+    </Report>
+    <Report instance="aapl-20190928.xml">
+      <HtmlFileName>R3.htm</HtmlFileName>
+      <LongName>0000003 - Statement - CONSOLIDATED STATEMENTS OF COMPREHENSIVE INCOME</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/CONSOLIDATEDSTATEMENTSOFCOMPREHENSIVEINCOME</Role>
+      <ShortName>CONSOLIDATED STATEMENTS OF COMPREHENSIVE INCOME</ShortName>
+      <MenuCategory>Statements</MenuCategory>
+      <Position>3</Position>
+    </Report>
+    <Report instance="aapl-20190928.xml">
+      <HtmlFileName>R4.htm</HtmlFileName>
+      <LongName>0000004 - Statement - CONSOLIDATED BALANCE SHEETS</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/CONSOLIDATEDBALANCESHEETS</Role>
+      <ShortName>CONSOLIDATED BALANCE SHEETS</ShortName>
+      <MenuCategory>Statements</MenuCategory>
+      <Position>4</Position>
+    </Report>
+    <Report instance="aapl-20190928.xml">
+      <HtmlFileName>R5.htm</HtmlFileName>
+      <LongName>0000005 - Statement - CONSOLIDATED BALANCE SHEETS (Parenthetical)</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/CONSOLIDATEDBALANCESHEETSParenthetical</Role>
+      <ShortName>CONSOLIDATED BALANCE SHEETS (Parenthetical)</ShortName>
+      <MenuCategory>Statements</MenuCategory>
+      <Position>5</Position>
+    </Report>
+    <Report instance="aapl-20190928.xml">
+      <HtmlFileName>R6.htm</HtmlFileName>
+      <LongName>0000006 - Statement - CONSOLIDATED STATEMENTS OF SHAREHOLDERS' EQUITY</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/CONSOLIDATEDSTATEMENTSOFSHAREHOLDERSEQUITY</Role>
+      <ShortName>CONSOLIDATED STATEMENTS OF SHAREHOLDERS' EQUITY</ShortName>
+      <MenuCategory>Statements</MenuCategory>
+      <Position>6</Position>
+    </Report>
+    <Report instance="aapl-20190928.xml">
+      <HtmlFileName>R7.htm</HtmlFileName>
+      <LongName>0000007 - Statement - CONSOLIDATED STATEMENTS OF CASH FLOWS</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/CONSOLIDATEDSTATEMENTSOFCASHFLOWS</Role>
+      <ShortName>CONSOLIDATED STATEMENTS OF CASH FLOWS</ShortName>
+      <MenuCategory>Statements</MenuCategory>
+      <Position>7</Position>
+    </Report>
+    <Report instance="aapl-20190928.xml">
+      <HtmlFileName>R60.htm</HtmlFileName>
+      <LongName>0000060 - Disclosure - Shareholders' Equity - Additional Information (Details)</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.apple.com/role/ShareholdersEquityAdditionalInformationDetails</Role>
+      <ShortName>Shareholders' Equity - Additional Information (Details)</ShortName>
+      <MenuCategory>Details</MenuCategory>
+      <Position>60</Position>
+    </Report>
+    <Report>
+      <LongName>All Reports</LongName>
+      <ReportType>Book</ReportType>
+      <ShortName>All Reports</ShortName>
+    </Report>
+  </MyReports>
+</FilingSummary>`
+
+//comprehensiveLossFilingSummary is laid out like an older filing summary, without menu categories, of a company whose
+//only statement of operations is a statement of comprehensive loss
+const comprehensiveLossFilingSummary = `<?xml version="1.0" encoding="utf-8"?>
+<FilingSummary>
+  <Version>2.4.0.6</Version>
+  <ReportFormat>Html</ReportFormat>
+  <MyReports>
+    <Report>
+      <HtmlFileName>R2.htm</HtmlFileName>
+      <LongName>0002 - Statement - Consolidated Balance Sheets</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.example.com/role/ConsolidatedBalanceSheets</Role>
+      <ShortName>Consolidated Balance Sheets</ShortName>
+      <Position>2</Position>
+    </Report>
+    <Report>
+      <HtmlFileName>R4.htm</HtmlFileName>
+      <LongName>0004 - Statement - Consolidated Statements of Comprehensive Loss</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.example.com/role/ConsolidatedStatementsOfComprehensiveLoss</Role>
+      <ShortName>Consolidated Statements of Comprehensive Loss</ShortName>
+      <Position>4</Position>
+    </Report>
+    <Report>
+      <HtmlFileName>R5.htm</HtmlFileName>
+      <LongName>0005 - Statement - Consolidated Statements of Cash Flows</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.example.com/role/ConsolidatedStatementsOfCashFlows</Role>
+      <ShortName>Consolidated Statements of Cash Flows</ShortName>
+      <Position>5</Position>
+    </Report>
+    <Report>
+      <HtmlFileName>R9.htm</HtmlFileName>
+      <LongName>0009 - Disclosure - Balance Sheet Components</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.example.com/role/BalanceSheetComponents</Role>
+      <ShortName>Balance Sheet Components</ShortName>
+      <Position>9</Position>
+    </Report>
+    <Report>
+      <HtmlFileName>R12.htm</HtmlFileName>
+      <LongName>0012 - Disclosure - Accumulated Other Comprehensive Loss</LongName>
+      <ReportType>Sheet</ReportType>
+      <Role>http://www.example.com/role/AccumulatedOtherComprehensiveLoss</Role>
+      <ShortName>Accumulated Other Comprehensive Loss</ShortName>
+      <Position>12</Position>
+    </Report>
+  </MyReports>
+</FilingSummary>`
+
+const testFilingURL = "https://www.sec.gov/Archives/edgar/data/320193/000032019319000119"
+
+func parseTestFilingSummary(t *testing.T, data string) FilingSummary {
+	var filingSummary FilingSummary
+	if err := xml.Unmarshal([]byte(data), &filingSummary); err != nil {
+		t.Fatal(err)
+	}
+	return filingSummary
+}
+
+func TestClassifyStatements(t *testing.T) {
+	tests := []struct {
+		name       string
+		summary    string
+		statement  string
+		file       string
+		confidence float64
+		candidates int
+	}{
+		{"balance sheet by name, role and menu", appleFilingSummary, StatementBalanceSheet, "R4.htm", 1, 1},
+		{"statements of operations are the income statement", appleFilingSummary, StatementIncomeStatement, "R2.htm", 1, 1},
+		{"comprehensive income is its own statement", appleFilingSummary, StatementComprehensiveIncome, "R3.htm", 1, 1},
+		{"shareholders' equity statement, not its details", appleFilingSummary, StatementStockholdersEquity, "R6.htm", 1, 1},
+		{"cash flows", appleFilingSummary, StatementCashFlowStatement, "R7.htm", 1, 1},
+		{"parenthetical is excluded from the balance sheet", appleFilingSummary, StatementParenthetical, "R5.htm", 1, 1},
+		{"a statement outranks a note of the same name", comprehensiveLossFilingSummary, StatementBalanceSheet, "R2.htm", 1, 2},
+		{"accumulated other comprehensive loss isn't a statement", comprehensiveLossFilingSummary, StatementComprehensiveIncome, "R4.htm", 1, 1},
+		{"no statement of operations", comprehensiveLossFilingSummary, StatementIncomeStatement, "", 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidates := ClassifyStatements(parseTestFilingSummary(t, test.summary), testFilingURL, DefaultStatementRules()).All(test.statement)
+			if len(candidates) != test.candidates {
+				t.Fatalf("got %d candidates %+v, want %d", len(candidates), candidates, test.candidates)
+			}
+			if len(candidates) == 0 {
+				return
+			}
+			best := candidates[0]
+			if best.Report.HtmlFileName != test.file || best.URL != testFilingURL+"/"+test.file {
+				t.Errorf("best = %s %s, want %s", best.Report.HtmlFileName, best.URL, test.file)
+			}
+			if best.Confidence < test.confidence-1e-9 || best.Confidence > test.confidence+1e-9 {
+				t.Errorf("confidence = %v, want %v", best.Confidence, test.confidence)
+			}
+		})
+	}
+}
+
+func TestClassifyStatementsPriority(t *testing.T) {
+	rules := StatementRules{Rules: []StatementRule{
+		{Statement: StatementIncomeStatement, Patterns: []string{"statements of operations"}, Priority: 1},
+		{Statement: StatementIncomeStatement, Patterns: []string{"statements of income"}, Priority: 10},
+	}}
+	var filingSummary FilingSummary
+	filingSummary.MyReports.Report = []FilingSummaryReport{
+		{HtmlFileName: "R2.htm", LongName: "0000002 - Statement - Consolidated Statements of Operations", MenuCategory: "Statements", Position: "2"},
+		{HtmlFileName: "R3.htm", LongName: "0000003 - Statement - Consolidated Statements of Income", MenuCategory: "Statements", Position: "3"},
+	}
+	candidates := ClassifyStatements(filingSummary, testFilingURL, rules).All(StatementIncomeStatement)
+	if len(candidates) != 2 || candidates[0].Report.HtmlFileName != "R3.htm" || candidates[1].Report.HtmlFileName != "R2.htm" {
+		t.Errorf("got %+v, want the higher priority rule's R3.htm first", candidates)
+	}
+}
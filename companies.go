@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
+)
+
+//CompanyTicker is a ticker listed for a CIK in company_tickers.json or company_tickers_exchange.json
+type CompanyTicker struct {
+	CIK      string
+	Ticker   string
+	Name     string
+	Exchange string
+}
+
+//CompanyFormerName is a name a company used before, from its submissions document
+type CompanyFormerName struct {
+	Name string
+	From bigquery.NullTimestamp
+	To   bigquery.NullTimestamp
+}
+
+//CompanyVersion is the state of a company from ValidFrom until its next version. A version is only added when
+//something about the company changed, Listed is false once the company left the tickers files (delisted or merged)
+type CompanyVersion struct {
+	CIK                  string
+	Name                 string
+	Tickers              []string
+	Exchanges            []string
+	Listed               bool
+	EntityType           string
+	SIC                  string
+	SICDescription       string
+	StateOfIncorporation string
+	BusinessState        string
+	EIN                  string
+	FiscalYearEnd        string
+	FormerNames          []CompanyFormerName
+	AttributesHash       string
+	ValidFrom            time.Time
+}
+
+//ParseCompanyTickers reads company_tickers.json, an object of {"cik_str", "ticker", "title"} entries
+func ParseCompanyTickers(data []byte) ([]CompanyTicker, error) {
+	var entries map[string]struct {
+		CIK    json.Number `json:"cik_str"`
+		Ticker string      `json:"ticker"`
+		Title  string      `json:"title"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	//keep the file's order, a company's main ticker comes first
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i])
+		b, _ := strconv.Atoi(keys[j])
+		return a < b
+	})
+	var tickers []CompanyTicker
+	for _, key := range keys {
+		entry := entries[key]
+		tickers = append(tickers, CompanyTicker{CIK: normalizeCIK(entry.CIK.String()), Ticker: entry.Ticker, Name: entry.Title})
+	}
+	return tickers, nil
+}
+
+//ParseCompanyTickersExchange reads company_tickers_exchange.json, a table of rows named by its fields
+func ParseCompanyTickersExchange(data []byte) ([]CompanyTicker, error) {
+	var table struct {
+		Fields []string            `json:"fields"`
+		Data   [][]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, err
+	}
+	var tickers []CompanyTicker
+	for _, row := range table.Data {
+		fields := make(map[string]string)
+		for i, field := range table.Fields {
+			if i >= len(row) {
+				break
+			}
+			var value interface{}
+			json.Unmarshal(row[i], &value)
+			switch v := value.(type) {
+			case string:
+				fields[field] = v
+			case float64:
+				fields[field] = fmt.Sprintf("%.0f", v)
+			}
+		}
+		tickers = append(tickers, CompanyTicker{CIK: normalizeCIK(fields["cik"]), Ticker: fields["ticker"], Name: fields["name"], Exchange: fields["exchange"]})
+	}
+	return tickers, nil
+}
+
+//normalizeCIK drops the zero padding so CIKs join with the unpadded ones from the EDGAR index
+func normalizeCIK(cik string) string {
+	cik = strings.TrimLeft(strings.TrimSpace(cik), "0")
+	if cik == "" {
+		return "0"
+	}
+	return cik
+}
+
+//BuildCompanySnapshot combines the tickers files and submissions documents into the current state of every company.
+//Each company starts from its current stored version and only the fields the given sources supply are replaced, so a
+//run with just some of the files keeps what the others said. Submissions documents are preferred for names, tickers
+//and exchanges as they are per company and complete
+func BuildCompanySnapshot(tickers []CompanyTicker, submissions []SubmissionsCompany, current map[string]CompanyVersion) []CompanyVersion {
+	companies := make(map[string]*CompanyVersion)
+	company := func(cik string) *CompanyVersion {
+		if companies[cik] == nil {
+			version := current[cik]
+			version.CIK = cik
+			companies[cik] = &version
+		}
+		return companies[cik]
+	}
+	listed := make(map[string]bool)
+	for _, ticker := range tickers {
+		version := company(ticker.CIK)
+		//the tickers files replace the stored tickers, the first time a company is seen in them
+		if !listed[ticker.CIK] {
+			listed[ticker.CIK] = true
+			version.Listed = true
+			version.Tickers = nil
+			version.Exchanges = nil
+			if ticker.Name != "" {
+				version.Name = ticker.Name
+			}
+		}
+		//the tickers files have a row per ticker, company_tickers.json without the exchange
+		found := false
+		for i, existing := range version.Tickers {
+			if existing == ticker.Ticker {
+				found = true
+				if version.Exchanges[i] == "" {
+					version.Exchanges[i] = ticker.Exchange
+				}
+			}
+		}
+		if !found {
+			exchange := ticker.Exchange
+			if exchange == "" {
+				exchange = storedExchange(current[ticker.CIK], ticker.Ticker)
+			}
+			version.Tickers = append(version.Tickers, ticker.Ticker)
+			version.Exchanges = append(version.Exchanges, exchange)
+		}
+	}
+	for _, document := range submissions {
+		version := company(normalizeCIK(document.CIK.String()))
+		version.Name = document.Name
+		if len(document.Tickers) > 0 {
+			version.Listed = true
+			version.Tickers = document.Tickers
+			version.Exchanges = make([]string, len(document.Tickers))
+			copy(version.Exchanges, document.Exchanges)
+		}
+		version.EntityType = document.EntityType
+		version.SIC = document.SIC
+		version.SICDescription = document.SICDescription
+		version.StateOfIncorporation = document.StateOfIncorporation
+		version.BusinessState = document.Addresses["business"].StateOrCountry
+		version.EIN = document.EIN
+		version.FiscalYearEnd = document.FiscalYearEnd
+		version.FormerNames = nil
+		for _, former := range document.FormerNames {
+			formerName := CompanyFormerName{Name: former.Name}
+			if !former.From.IsZero() {
+				formerName.From = bigquery.NullTimestamp{Timestamp: former.From, Valid: true}
+			}
+			if !former.To.IsZero() {
+				formerName.To = bigquery.NullTimestamp{Timestamp: former.To, Valid: true}
+			}
+			version.FormerNames = append(version.FormerNames, formerName)
+		}
+	}
+	var snapshot []CompanyVersion
+	for _, version := range companies {
+		snapshot = append(snapshot, *version)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].CIK < snapshot[j].CIK })
+	return snapshot
+}
+
+//storedExchange is the exchange a stored version lists a ticker on, for tickers files that don't give it
+func storedExchange(version CompanyVersion, ticker string) string {
+	for i, existing := range version.Tickers {
+		if existing == ticker && i < len(version.Exchanges) {
+			return version.Exchanges[i]
+		}
+	}
+	return ""
+}
+
+//companyAttributesHash fingerprints everything about a company version except when it was seen
+func companyAttributesHash(version CompanyVersion) string {
+	version.AttributesHash = ""
+	version.ValidFrom = time.Time{}
+	data, _ := json.Marshal(version)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+//ChangedCompanies returns the versions to append to company_versions for a snapshot seen at asOf, given the current
+//version of every company already stored. Companies that are new or changed get a version. When the snapshot covers
+//every listed company, listed companies missing from it get a delisted version
+func ChangedCompanies(snapshot []CompanyVersion, current map[string]CompanyVersion, asOf time.Time, complete bool) []CompanyVersion {
+	var changed []CompanyVersion
+	seen := make(map[string]bool)
+	for _, version := range snapshot {
+		seen[version.CIK] = true
+		version.AttributesHash = companyAttributesHash(version)
+		version.ValidFrom = asOf
+		if stored, ok := current[version.CIK]; !ok || stored.AttributesHash != version.AttributesHash {
+			changed = append(changed, version)
+		}
+	}
+	if !complete {
+		return changed
+	}
+	var ciks []string
+	for cik := range current {
+		ciks = append(ciks, cik)
+	}
+	sort.Strings(ciks)
+	for _, cik := range ciks {
+		version := current[cik]
+		if seen[cik] || !version.Listed {
+			continue
+		}
+		version.Listed = false
+		version.Tickers = nil
+		version.Exchanges = nil
+		version.AttributesHash = companyAttributesHash(version)
+		version.ValidFrom = asOf
+		changed = append(changed, version)
+	}
+	return changed
+}
+
+//currentCompanyVersionsQuery reads the latest version of every company
+const currentCompanyVersionsQuery = "SELECT * EXCEPT(VersionNumber) FROM (\n" +
+	"SELECT *, ROW_NUMBER() OVER (PARTITION BY CIK ORDER BY ValidFrom DESC) AS VersionNumber FROM `%s.SEC.company_versions`\n" +
+	") WHERE VersionNumber = 1"
+
+//CompaniesViewQuery gives every company version the interval it was valid for. ValidTo is null on the current version,
+//a ticker belongs to a company at time t when t is in [ValidFrom, ValidTo) of a version listing it
+func CompaniesViewQuery(projectName string) string {
+	return fmt.Sprintf("SELECT * EXCEPT(AttributesHash), LEAD(ValidFrom) OVER (PARTITION BY CIK ORDER BY ValidFrom) AS ValidTo,\n"+
+		"LEAD(ValidFrom) OVER (PARTITION BY CIK ORDER BY ValidFrom) IS NULL AS IsCurrent\n"+
+		"FROM `%s.SEC.company_versions`", projectName)
+}
+
+//companiesCommand updates the company_versions history from the SEC's tickers files and submissions documents
+//downloaded to local disk, and keeps the companies view over it
+func companiesCommand(args []string) {
+	flags := flag.NewFlagSet("companies", flag.ExitOnError)
+	tickersPath := flags.String("tickers", "", "path to company_tickers.json")
+	exchangePath := flags.String("exchange", "", "path to company_tickers_exchange.json")
+	submissionsDir := flags.String("submissions", "", "directory of CIK##########.json submissions documents")
+	asOfFlag := flags.String("as-of", "", "when the files were downloaded (RFC 3339 or YYYY-MM-DD), defaults to now")
+	flags.Parse(args)
+	if *tickersPath == "" && *exchangePath == "" && *submissionsDir == "" {
+		log.Fatal("pass -tickers, -exchange or -submissions")
+	}
+	asOf := time.Now()
+	if *asOfFlag != "" {
+		var err error
+		if asOf, err = time.Parse(time.RFC3339, *asOfFlag); err != nil {
+			if asOf, err = time.ParseInLocation("2006-01-02", *asOfFlag, edgarLocation); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	var tickers []CompanyTicker
+	for _, source := range []struct {
+		path  string
+		parse func([]byte) ([]CompanyTicker, error)
+	}{{*tickersPath, ParseCompanyTickers}, {*exchangePath, ParseCompanyTickersExchange}} {
+		if source.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(source.path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		parsed, err := source.parse(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tickers = append(tickers, parsed...)
+	}
+	var submissions []SubmissionsCompany
+	if *submissionsDir != "" {
+		var err error
+		if submissions, err = LoadSubmissionsDir(*submissionsDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Println("Please enter in your Google Cloud project name: ")
+	var projectName string
+	fmt.Scanln(&projectName)
+	ctx := context.Background()
+	bq, err := bigquery.NewClient(ctx, projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bq.Close()
+	ds := bq.Dataset("SEC")
+	companyVersionsTable := ds.Table("company_versions")
+	companyVersionsSchema, _ := bigquery.InferSchema(CompanyVersion{})
+	if err := createTable(ctx, companyVersionsTable, companyVersionsSchema); err != nil {
+		fmt.Println(err)
+	}
+	if err := ds.Table("companies").Create(ctx, &bigquery.TableMetadata{ViewQuery: CompaniesViewQuery(projectName)}); err != nil {
+		fmt.Println(err)
+	}
+
+	current := make(map[string]CompanyVersion)
+	it, err := bq.Query(fmt.Sprintf(currentCompanyVersionsQuery, projectName)).Read(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for {
+		var version CompanyVersion
+		err := it.Next(&version)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		current[version.CIK] = version
+	}
+	snapshot := BuildCompanySnapshot(tickers, submissions, current)
+
+	//only a tickers file lists every listed company, a directory of submissions may be a watchlist
+	changed := ChangedCompanies(snapshot, current, asOf, *tickersPath != "" || *exchangePath != "")
+	fmt.Println(len(changed), "of", len(snapshot), "companies changed")
+	companyVersionsInserter := companyVersionsTable.Inserter()
+	for start := 0; start < len(changed); start += 500 {
+		end := start + 500
+		if end > len(changed) {
+			end = len(changed)
+		}
+		if err := companyVersionsInserter.Put(ctx, changed[start:end]); err != nil {
+			fmt.Println("Can't upload company versions")
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//appleSubmissions is the company part of Apple's CIK0000320193.json submissions document
+const appleSubmissions = `{"cik":"320193","entityType":"operating","sic":"3571","sicDescription":"Electronic Computers","name":"Apple Inc.","tickers":["AAPL"],"exchanges":["Nasdaq"],"ein":"942404110","fiscalYearEnd":"0928","stateOfIncorporation":"CA",
+"addresses":{"mailing":{"street1":"ONE APPLE PARK WAY","city":"CUPERTINO","stateOrCountry":"CA","zipCode":"95014"},"business":{"street1":"ONE APPLE PARK WAY","city":"CUPERTINO","stateOrCountry":"CA","zipCode":"95014"}},
+"formerNames":[{"name":"APPLE INC","from":"2007-01-10T00:00:00.000Z","to":"2019-08-05T00:00:00.000Z"},{"name":"APPLE COMPUTER INC","from":"1994-01-26T00:00:00.000Z","to":"2007-01-04T00:00:00.000Z"}]}`
+
+//metaSubmissions is the company part of Meta's submissions document before its ticker changed from FB to META
+const metaSubmissions = `{"cik":"1326801","entityType":"operating","sic":"7370","sicDescription":"Services-Computer Programming, Data Processing, Etc.","name":"Meta Platforms, Inc.","tickers":["FB"],"exchanges":["Nasdaq"],"ein":"201665019","fiscalYearEnd":"1231","stateOfIncorporation":"DE",
+"addresses":{"business":{"street1":"1601 WILLOW ROAD","city":"MENLO PARK","stateOrCountry":"CA","zipCode":"94025"}},
+"formerNames":[{"name":"FACEBOOK INC","from":"2005-07-08T00:00:00.000Z","to":"2021-10-28T00:00:00.000Z"}]}`
+
+//company_tickers.json as downloaded before and after Meta's ticker changed
+const (
+	tickersWithFB    = `{"0":{"cik_str":320193,"ticker":"AAPL","title":"Apple Inc."},"1":{"cik_str":1326801,"ticker":"FB","title":"Meta Platforms, Inc."}}`
+	tickersWithMETA  = `{"0":{"cik_str":320193,"ticker":"AAPL","title":"Apple Inc."},"1":{"cik_str":1326801,"ticker":"META","title":"Meta Platforms, Inc."}}`
+	tickersAppleOnly = `{"0":{"cik_str":320193,"ticker":"AAPL","title":"Apple Inc."}}`
+)
+
+var (
+	firstSeen  = time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC)
+	secondSeen = time.Date(2022, time.June, 9, 0, 0, 0, 0, time.UTC)
+)
+
+func parseTestSubmissions(t *testing.T, documents ...string) []SubmissionsCompany {
+	var submissions []SubmissionsCompany
+	for _, data := range documents {
+		company, err := ParseSubmissionsCompany([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		submissions = append(submissions, company)
+	}
+	return submissions
+}
+
+func parseTestTickers(t *testing.T, data string) []CompanyTicker {
+	tickers, err := ParseCompanyTickers([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tickers
+}
+
+//storedVersions is what company_versions holds after a full run on the first download
+func storedVersions(t *testing.T) map[string]CompanyVersion {
+	snapshot := BuildCompanySnapshot(parseTestTickers(t, tickersWithFB), parseTestSubmissions(t, appleSubmissions, metaSubmissions), nil)
+	current := make(map[string]CompanyVersion)
+	for _, version := range ChangedCompanies(snapshot, nil, firstSeen, true) {
+		current[version.CIK] = version
+	}
+	return current
+}
+
+func TestChangedCompanies(t *testing.T) {
+	tests := []struct {
+		name       string
+		tickers    string
+		submission []string
+		complete   bool
+		want       map[string][]string
+		delisted   []string
+	}{
+		{
+			name:       "the same files again add no version",
+			tickers:    tickersWithFB,
+			submission: []string{appleSubmissions, metaSubmissions},
+			complete:   true,
+		},
+		{
+			name:     "a ticker change in the tickers file alone adds a version",
+			tickers:  tickersWithMETA,
+			complete: true,
+			want:     map[string][]string{"1326801": {"META"}},
+		},
+		{
+			name:     "a company missing from a complete tickers file is delisted",
+			tickers:  tickersAppleOnly,
+			complete: true,
+			want:     map[string][]string{"1326801": nil},
+			delisted: []string{"1326801"},
+		},
+		{
+			name:       "submissions documents alone don't delist the companies they leave out",
+			submission: []string{appleSubmissions},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current := storedVersions(t)
+			var tickers []CompanyTicker
+			if test.tickers != "" {
+				tickers = parseTestTickers(t, test.tickers)
+			}
+			snapshot := BuildCompanySnapshot(tickers, parseTestSubmissions(t, test.submission...), current)
+			changed := ChangedCompanies(snapshot, current, secondSeen, test.complete)
+			if len(changed) != len(test.want) {
+				t.Fatalf("got %d changed companies %+v, want %d", len(changed), changed, len(test.want))
+			}
+			delisted := make(map[string]bool)
+			for _, cik := range test.delisted {
+				delisted[cik] = true
+			}
+			for _, version := range changed {
+				wantTickers, ok := test.want[version.CIK]
+				if !ok {
+					t.Fatalf("CIK %s changed, it shouldn't have", version.CIK)
+				}
+				if !reflect.DeepEqual(version.Tickers, wantTickers) {
+					t.Errorf("CIK %s tickers = %v, want %v", version.CIK, version.Tickers, wantTickers)
+				}
+				if version.Listed == delisted[version.CIK] {
+					t.Errorf("CIK %s Listed = %v", version.CIK, version.Listed)
+				}
+				if !version.ValidFrom.Equal(secondSeen) || version.AttributesHash == current[version.CIK].AttributesHash {
+					t.Errorf("CIK %s version isn't new: %v %s", version.CIK, version.ValidFrom, version.AttributesHash)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildCompanySnapshotKeepsFieldsOfOtherSources(t *testing.T) {
+	current := storedVersions(t)
+	snapshot := BuildCompanySnapshot(parseTestTickers(t, tickersWithMETA), nil, current)
+	if len(snapshot) != 2 {
+		t.Fatalf("got %d companies, want 2", len(snapshot))
+	}
+	meta := snapshot[0]
+	stored := current["1326801"]
+	if meta.CIK != "1326801" || !reflect.DeepEqual(meta.Tickers, []string{"META"}) {
+		t.Fatalf("got %s %v, want 1326801 [META]", meta.CIK, meta.Tickers)
+	}
+	//company_tickers.json has no exchanges, sic codes or former names, so those stay as the submissions document gave them
+	if !reflect.DeepEqual(meta.Exchanges, []string{""}) {
+		t.Errorf("Exchanges = %q, want the new ticker's exchange left unknown", meta.Exchanges)
+	}
+	if meta.SIC != stored.SIC || meta.EIN != stored.EIN || meta.StateOfIncorporation != stored.StateOfIncorporation || meta.BusinessState != "CA" || !reflect.DeepEqual(meta.FormerNames, stored.FormerNames) {
+		t.Errorf("got %+v, want the stored details of %+v", meta, stored)
+	}
+	if apple := snapshot[1]; !reflect.DeepEqual(apple.Exchanges, []string{"Nasdaq"}) || apple.SIC != "3571" {
+		t.Errorf("got %+v, want Apple's stored exchange and SIC code", apple)
+	}
+}
+
+func TestLoadSubmissionsDir(t *testing.T) {
+	dir := t.TempDir()
+	document := strings.TrimSuffix(appleSubmissions, "}") + `,"filings":{"recent":{"accessionNumber":["0000320193-23-000106"],"form":["10-K"]},"files":[{"name":"CIK0000320193-submissions-001.json","filingCount":1}]}}`
+	files := map[string]string{"CIK0000320193.json": document, "CIK0000320193-submissions-001.json": `{"accessionNumber":["0000320193-94-000016"],"form":["10-Q"]}`}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	companies, err := LoadSubmissionsDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(companies) != 1 || companies[0].CIK.String() != "320193" || companies[0].Name != "Apple Inc." || len(companies[0].FormerNames) != 2 {
+		t.Errorf("got %+v, want Apple's details from its submissions document alone", companies)
+	}
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+//CompanyFactValue is a numeric value without dimensions as one filing reported it, with the filing's cover page
+//details the SEC's companyfacts and frames documents carry
+type CompanyFactValue struct {
+	CIK                string
+	Concept            string
+	Item               string
+	Unit               string
+	Currency           string
+	PeriodStart        bigquery.NullDate
+	PeriodEnd          bigquery.NullDate
+	PeriodMonths       int
+	NumericValue       *big.Rat
+	AccessionNumber    string
+	Form               string
+	FilingDate         bigquery.NullDate
+	AcceptanceDateTime bigquery.NullTimestamp
+	EntityName         string
+	FiscalYearFocus    string
+	FiscalPeriodFocus  string
+}
+
+//CompanyFactsFact is one reported value in a companyfacts document. Frame is only set on the most recently filed
+//value of each calendar period, as on the SEC's documents
+type CompanyFactsFact struct {
+	Start string      `json:"start,omitempty"`
+	End   string      `json:"end"`
+	Val   json.Number `json:"val"`
+	Accn  string      `json:"accn"`
+	FY    int         `json:"fy,omitempty"`
+	FP    string      `json:"fp,omitempty"`
+	Form  string      `json:"form"`
+	Filed string      `json:"filed"`
+	Frame string      `json:"frame,omitempty"`
+}
+
+//CompanyFactsConcept is every reported value of a concept grouped by unit
+type CompanyFactsConcept struct {
+	Label       string                        `json:"label"`
+	Description *string                       `json:"description"`
+	Units       map[string][]CompanyFactsFact `json:"units"`
+}
+
+//CompanyFacts is the companyfacts document of a company, its concepts grouped by taxonomy
+type CompanyFacts struct {
+	CIK        int                                        `json:"cik"`
+	EntityName string                                     `json:"entityName"`
+	Facts      map[string]map[string]*CompanyFactsConcept `json:"facts"`
+}
+
+//FrameFact is one company's value in a frames document
+type FrameFact struct {
+	Accn       string      `json:"accn"`
+	CIK        int         `json:"cik"`
+	EntityName string      `json:"entityName"`
+	Start      string      `json:"start,omitempty"`
+	End        string      `json:"end"`
+	Val        json.Number `json:"val"`
+}
+
+//Frame is the frames document of a concept, unit and calendar period, one value per company
+type Frame struct {
+	Taxonomy    string      `json:"taxonomy"`
+	Tag         string      `json:"tag"`
+	CCP         string      `json:"ccp"`
+	UOM         string      `json:"uom"`
+	Label       string      `json:"label"`
+	Description *string     `json:"description"`
+	Pts         int         `json:"pts"`
+	Data        []FrameFact `json:"data"`
+}
+
+//secUnit names a value's unit the way the SEC's json does (USD, USD/shares, shares, pure), empty when it can't
+func secUnit(unit string, currency string) string {
+	switch unit {
+	case UnitMonetary:
+		return currency
+	case UnitPerShare:
+		if currency == "" {
+			return ""
+		}
+		return currency + "/shares"
+	case UnitShares, UnitPure:
+		return unit
+	}
+	return ""
+}
+
+//CalendarFrame is the calendar period a value is aligned to, CY2019 for annual durations, CY2019Q4 for quarterly ones
+//and CY2019Q4I for instants, taking the period the value's dates are closest to. Other durations have no frame
+func CalendarFrame(start bigquery.NullDate, end bigquery.NullDate, months int) string {
+	if !end.Valid {
+		return ""
+	}
+	if !start.Valid {
+		//an instant belongs to the quarter whose last day is closest to it
+		date := end.Date.AddDays(-45)
+		return fmt.Sprintf("CY%dQ%dI", date.Year, (int(date.Month)+2)/3)
+	}
+	middle := start.Date.AddDays(end.Date.DaysSince(start.Date) / 2)
+	switch months {
+	case 3:
+		return fmt.Sprintf("CY%dQ%d", middle.Year, (int(middle.Month)+2)/3)
+	case 12:
+		return fmt.Sprintf("CY%d", middle.Year)
+	}
+	return ""
+}
+
+//jsonNumber writes an exact decimal as a json number
+func jsonNumber(value *big.Rat) json.Number {
+	if value.IsInt() {
+		return json.Number(value.Num().String())
+	}
+	return json.Number(strings.TrimRight(value.FloatString(10), "0"))
+}
+
+func dateString(date bigquery.NullDate) string {
+	if !date.Valid {
+		return ""
+	}
+	return date.Date.String()
+}
+
+//splitConcept splits a concept like us-gaap:Revenues into its taxonomy and tag
+func splitConcept(concept string) (string, string) {
+	if i := strings.Index(concept, ":"); i >= 0 {
+		return concept[:i], concept[i+1:]
+	}
+	return "", concept
+}
+
+//filedBefore orders reports of the same value by when they were made public
+func filedBefore(a CompanyFactValue, b CompanyFactValue) bool {
+	if !a.AcceptanceDateTime.Timestamp.Equal(b.AcceptanceDateTime.Timestamp) {
+		return a.AcceptanceDateTime.Timestamp.Before(b.AcceptanceDateTime.Timestamp)
+	}
+	if a.FilingDate.Date != b.FilingDate.Date {
+		return a.FilingDate.Date.Before(b.FilingDate.Date)
+	}
+	return a.AccessionNumber < b.AccessionNumber
+}
+
+//framedValue is a value with its SEC unit and, when it is the latest report of its calendar period, its frame
+type framedValue struct {
+	CompanyFactValue
+	unit  string
+	frame string
+}
+
+//frameValues sorts the values by company, concept, unit, period and filing and gives the most recently filed value
+//of every company, concept, unit and calendar period its frame. Values without an SEC unit are dropped
+func frameValues(values []CompanyFactValue) []framedValue {
+	var framed []framedValue
+	for _, value := range values {
+		if unit := secUnit(value.Unit, value.Currency); unit != "" && value.NumericValue != nil && value.PeriodEnd.Valid {
+			framed = append(framed, framedValue{CompanyFactValue: value, unit: unit})
+		}
+	}
+	sort.SliceStable(framed, func(i, j int) bool {
+		a, b := framed[i], framed[j]
+		if a.CIK != b.CIK {
+			return a.CIK < b.CIK
+		}
+		if a.Concept != b.Concept {
+			return a.Concept < b.Concept
+		}
+		if a.unit != b.unit {
+			return a.unit < b.unit
+		}
+		if a.PeriodEnd.Date != b.PeriodEnd.Date {
+			return a.PeriodEnd.Date.Before(b.PeriodEnd.Date)
+		}
+		if a.PeriodStart != b.PeriodStart {
+			return !a.PeriodStart.Valid || (b.PeriodStart.Valid && a.PeriodStart.Date.After(b.PeriodStart.Date))
+		}
+		return filedBefore(a.CompanyFactValue, b.CompanyFactValue)
+	})
+	latest := make(map[string]int)
+	for i, value := range framed {
+		frame := CalendarFrame(value.PeriodStart, value.PeriodEnd, value.PeriodMonths)
+		if frame == "" {
+			continue
+		}
+		key := strings.Join([]string{value.CIK, value.Concept, value.unit, frame}, "|")
+		if j, ok := latest[key]; !ok || filedBefore(framed[j].CompanyFactValue, value.CompanyFactValue) {
+			latest[key] = i
+		}
+	}
+	for key, i := range latest {
+		framed[i].frame = key[strings.LastIndex(key, "|")+1:]
+	}
+	return framed
+}
+
+//BuildCompanyFacts groups the values into one companyfacts document per company. The entity name and each concept's
+//label come from the company's latest filing
+func BuildCompanyFacts(values []CompanyFactValue) []CompanyFacts {
+	var documents []CompanyFacts
+	var currentCIK string
+	var latestFiling CompanyFactValue
+	var labelFiled map[string]CompanyFactValue
+	for _, value := range frameValues(values) {
+		if len(documents) == 0 || value.CIK != currentCIK {
+			currentCIK = value.CIK
+			cik, _ := strconv.Atoi(value.CIK)
+			documents = append(documents, CompanyFacts{CIK: cik, Facts: make(map[string]map[string]*CompanyFactsConcept)})
+			latestFiling = value.CompanyFactValue
+			labelFiled = make(map[string]CompanyFactValue)
+		}
+		document := &documents[len(documents)-1]
+		if !filedBefore(value.CompanyFactValue, latestFiling) {
+			latestFiling = value.CompanyFactValue
+			document.EntityName = value.EntityName
+		}
+		taxonomy, tag := splitConcept(value.Concept)
+		if document.Facts[taxonomy] == nil {
+			document.Facts[taxonomy] = make(map[string]*CompanyFactsConcept)
+		}
+		concept := document.Facts[taxonomy][tag]
+		if concept == nil {
+			concept = &CompanyFactsConcept{Units: make(map[string][]CompanyFactsFact)}
+			document.Facts[taxonomy][tag] = concept
+		}
+		if filed, ok := labelFiled[value.Concept]; !ok || !filedBefore(value.CompanyFactValue, filed) {
+			labelFiled[value.Concept] = value.CompanyFactValue
+			concept.Label = value.Item
+		}
+		fiscalYear, _ := strconv.Atoi(value.FiscalYearFocus)
+		concept.Units[value.unit] = append(concept.Units[value.unit], CompanyFactsFact{Start: dateString(value.PeriodStart), End: dateString(value.PeriodEnd), Val: jsonNumber(value.NumericValue), Accn: value.AccessionNumber, FY: fiscalYear, FP: value.FiscalPeriodFocus, Form: value.Form, Filed: dateString(value.FilingDate), Frame: value.frame})
+	}
+	return documents
+}
+
+//BuildFrames collects the framed values into one frames document per concept, unit and calendar period with a value
+//for every company, ordered by CIK
+func BuildFrames(values []CompanyFactValue) []Frame {
+	var frames []Frame
+	index := make(map[string]int)
+	labelFiled := make(map[string]CompanyFactValue)
+	for _, value := range frameValues(values) {
+		if value.frame == "" {
+			continue
+		}
+		key := strings.Join([]string{value.Concept, value.unit, value.frame}, "|")
+		i, ok := index[key]
+		if !ok {
+			i = len(frames)
+			index[key] = i
+			taxonomy, tag := splitConcept(value.Concept)
+			frames = append(frames, Frame{Taxonomy: taxonomy, Tag: tag, CCP: value.frame, UOM: value.unit})
+		}
+		if filed, ok := labelFiled[key]; !ok || !filedBefore(value.CompanyFactValue, filed) {
+			labelFiled[key] = value.CompanyFactValue
+			frames[i].Label = value.Item
+		}
+		cik, _ := strconv.Atoi(value.CIK)
+		frames[i].Data = append(frames[i].Data, FrameFact{Accn: value.AccessionNumber, CIK: cik, EntityName: value.EntityName, Start: dateString(value.PeriodStart), End: dateString(value.PeriodEnd), Val: jsonNumber(value.NumericValue)})
+		frames[i].Pts = len(frames[i].Data)
+	}
+	return frames
+}
+
+//CompanyFactsObject is where a company's document is written, named like the SEC's CIK##########.json
+func CompanyFactsObject(prefix string, cik int) string {
+	return fmt.Sprintf("%scompanyfacts/CIK%010d.json", prefix, cik)
+}
+
+//FrameObject is where a frames document is written, laid out like the SEC's frames API paths
+func FrameObject(prefix string, frame Frame) string {
+	return fmt.Sprintf("%sframes/%s/%s/%s/%s.json", prefix, frame.Taxonomy, frame.Tag, strings.ReplaceAll(frame.UOM, "/", "-per-"), frame.CCP)
+}
+
+//companyFactValuesQuery reads every numeric value without dimensions once per filing, with the filing's cover page,
+//ordered by the columns the documents are grouped by. Values a statement showed negated get the XBRL fact's sign back
+const companyFactValuesQuery = `SELECT v.*, c.EntityRegistrantName AS EntityName, c.DocumentFiscalYearFocus AS FiscalYearFocus, c.DocumentFiscalPeriodFocus AS FiscalPeriodFocus FROM (
+SELECT * EXCEPT(RowInFiling) FROM (
+SELECT CIK, Concept, Item, Unit, Currency, PeriodStart, PeriodEnd, PeriodMonths, IF(Negated, -NumericValue, NumericValue) AS NumericValue, AccessionNumber, Form, FilingDate, AcceptanceDateTime,
+ROW_NUMBER() OVER (PARTITION BY CIK, Concept, Unit, Currency, PeriodStart, PeriodEnd, AccessionNumber ORDER BY Statement) AS RowInFiling
+FROM ` + "`%[1]s.SEC.reported_values`" + `
+WHERE DimensionKey = '' AND NumericValue IS NOT NULL AND Concept != ''%[2]s
+) WHERE RowInFiling = 1
+) v LEFT JOIN (
+SELECT AccessionNumber, ANY_VALUE(EntityRegistrantName) AS EntityRegistrantName, ANY_VALUE(DocumentFiscalYearFocus) AS DocumentFiscalYearFocus, ANY_VALUE(DocumentFiscalPeriodFocus) AS DocumentFiscalPeriodFocus
+FROM ` + "`%[1]s.SEC.filing_cover`" + ` GROUP BY AccessionNumber
+) c USING (AccessionNumber)
+ORDER BY %[3]s`
+
+//readCompanyFactValues runs companyFactValuesQuery ordered by the given columns and hands fn the values of one group at
+//a time, a group being the consecutive rows with the same key, so the whole table is never held in memory
+func readCompanyFactValues(ctx context.Context, bq *bigquery.Client, projectName string, filter string, parameters []bigquery.QueryParameter, orderBy string, key func(CompanyFactValue) string, fn func([]CompanyFactValue) error) error {
+	q := bq.Query(fmt.Sprintf(companyFactValuesQuery, projectName, filter, orderBy))
+	q.Parameters = parameters
+	it, err := q.Read(ctx)
+	if err != nil {
+		return err
+	}
+	var group []CompanyFactValue
+	for {
+		var value CompanyFactValue
+		err := it.Next(&value)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(group) > 0 && key(value) != key(group[0]) {
+			if err := fn(group); err != nil {
+				return err
+			}
+			group = nil
+		}
+		group = append(group, value)
+	}
+	if len(group) == 0 {
+		return nil
+	}
+	return fn(group)
+}
+
+//writeJSONObject writes a document to Cloud Storage
+func writeJSONObject(ctx context.Context, bucket *storage.BucketHandle, name string, document interface{}) error {
+	writer := bucket.Object(name).NewWriter(ctx)
+	writer.ContentType = "application/json"
+	if err := json.NewEncoder(writer).Encode(document); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+//companyFactsCommand writes companyfacts and frames json built from reported_values to a Cloud Storage bucket, in
+//the same shapes as the SEC's xbrl api so code written against it can read our data
+func companyFactsCommand(args []string) {
+	flags := flag.NewFlagSet("companyfacts", flag.ExitOnError)
+	bucketName := flags.String("bucket", "", "Cloud Storage bucket to write the json to")
+	prefix := flags.String("prefix", "", "path in the bucket to write under, e.g. xbrl/")
+	cik := flags.String("cik", "", "only write the companyfacts document of this CIK, frames are skipped")
+	flags.Parse(args)
+	if *bucketName == "" {
+		log.Fatal("-bucket is required")
+	}
+
+	fmt.Println("Please enter in your Google Cloud project name: ")
+	var projectName string
+	fmt.Scanln(&projectName)
+	ctx := context.Background()
+	bq, err := bigquery.NewClient(ctx, projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bq.Close()
+	gcs, err := storage.NewClient(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gcs.Close()
+	bucket := gcs.Bucket(*bucketName)
+
+	filter := ""
+	var parameters []bigquery.QueryParameter
+	if *cik != "" {
+		filter = " AND CIK = @cik"
+		parameters = []bigquery.QueryParameter{{Name: "cik", Value: *cik}}
+	}
+	//companyfacts documents are built one company at a time and frames one concept and unit at a time
+	companies := 0
+	err = readCompanyFactValues(ctx, bq, projectName, filter, parameters, "CIK", func(value CompanyFactValue) string { return value.CIK }, func(values []CompanyFactValue) error {
+		for _, document := range BuildCompanyFacts(values) {
+			if err := writeJSONObject(ctx, bucket, CompanyFactsObject(*prefix, document.CIK), document); err != nil {
+				fmt.Println("Can't write companyfacts of", document.CIK)
+				return err
+			}
+			companies++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(companies, "companyfacts documents written")
+	if *cik != "" {
+		return
+	}
+	frames := 0
+	err = readCompanyFactValues(ctx, bq, projectName, filter, parameters, "Concept, Unit", func(value CompanyFactValue) string { return value.Concept + "|" + value.Unit }, func(values []CompanyFactValue) error {
+		for _, frame := range BuildFrames(values) {
+			if err := writeJSONObject(ctx, bucket, FrameObject(*prefix, frame), frame); err != nil {
+				fmt.Println("Can't write frame", frame.Taxonomy, frame.Tag, frame.UOM, frame.CCP)
+				return err
+			}
+			frames++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(frames, "frames documents written")
+}
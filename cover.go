@@ -0,0 +1,142 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/anaskhan96/soup"
+)
+
+//CoverSecurity is a class of securities registered on an exchange as listed on the cover page
+type CoverSecurity struct {
+	Class                string
+	TradingSymbol        string
+	SecurityExchangeName string
+	SharesOutstanding    *big.Rat `bigquery:",nullable"`
+}
+
+//FilingCover holds the document and entity information (dei) facts of a filing's cover page
+type FilingCover struct {
+	Year                               string
+	Quarter                            string
+	CIK                                string
+	AccessionNumber                    string
+	DocumentType                       string
+	DocumentPeriodEndDate              bigquery.NullDate
+	EntityRegistrantName               string
+	EntityCentralIndexKey              string
+	TradingSymbol                      string
+	SecurityExchangeName               string
+	EntityCommonStockSharesOutstanding *big.Rat `bigquery:",nullable"`
+	SharesOutstandingDate              bigquery.NullDate
+	EntityPublicFloat                  *big.Rat `bigquery:",nullable"`
+	PublicFloatDate                    bigquery.NullDate
+	DocumentFiscalYearFocus            string
+	DocumentFiscalPeriodFocus          string
+	CurrentFiscalYearEndDate           string
+	AmendmentFlag                      bool
+	EntityFilerCategory                string
+	Securities                         []CoverSecurity
+}
+
+//coverValue returns the first filled in cell of a cover page row with the column it was in
+func coverValue(row reportRow, columns []StatementColumn) (string, StatementColumn) {
+	for i, cell := range row.Cells {
+		if cell != "" && i < len(columns) {
+			return cell, columns[i]
+		}
+	}
+	return "", StatementColumn{}
+}
+
+//coverSecurity returns the security a cover page row is reported for, adding it the first time a class is seen
+func coverSecurity(cover *FilingCover, row reportRow) *CoverSecurity {
+	class := ""
+	for _, dimension := range row.Dimensions {
+		class = dimension.MemberLabel
+	}
+	for i := range cover.Securities {
+		if cover.Securities[i].Class == class {
+			return &cover.Securities[i]
+		}
+	}
+	cover.Securities = append(cover.Securities, CoverSecurity{Class: class})
+	return &cover.Securities[len(cover.Securities)-1]
+}
+
+//ParseCoverPage extracts the dei facts from the cover page report FilingSummary lists first. Filers with several
+//classes of stock report trading symbols and shares outstanding per class, these are kept in Securities and the
+//filing level shares outstanding is their total when no consolidated figure is given
+func ParseCoverPage(coverPage []byte, year string, qtr string, cik string, accessionNumber string) FilingCover {
+	doc := soup.HTMLParse(string(coverPage))
+	title, columns, rows := parseReportRows(doc)
+	scale := ParseStatementScale(title)
+	cover := FilingCover{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber}
+	classShares := new(big.Rat)
+	hasClassShares := false
+
+	for _, row := range rows {
+		value, column := coverValue(row, columns)
+		if value == "" {
+			continue
+		}
+		concept := xbrlConcept(row.Tag)
+		concept = concept[strings.Index(concept, ":")+1:]
+		switch concept {
+		case "DocumentType":
+			cover.DocumentType = value
+		case "DocumentPeriodEndDate":
+			if date, ok := parsePeriodDate(value); ok {
+				cover.DocumentPeriodEndDate = nullDate(date)
+			}
+		case "EntityRegistrantName":
+			cover.EntityRegistrantName = value
+		case "EntityCentralIndexKey":
+			cover.EntityCentralIndexKey = value
+		case "TradingSymbol":
+			if len(row.Dimensions) > 0 {
+				coverSecurity(&cover, row).TradingSymbol = value
+			}
+			if cover.TradingSymbol == "" {
+				cover.TradingSymbol = value
+			}
+		case "SecurityExchangeName":
+			if len(row.Dimensions) > 0 {
+				coverSecurity(&cover, row).SecurityExchangeName = value
+			}
+			if cover.SecurityExchangeName == "" {
+				cover.SecurityExchangeName = value
+			}
+		case "EntityCommonStockSharesOutstanding":
+			shares := NormalizeValue(value, "xbrli:sharesItemType", row.Label, scale).Number
+			cover.SharesOutstandingDate = nullDate(column.Period.End)
+			if len(row.Dimensions) > 0 {
+				coverSecurity(&cover, row).SharesOutstanding = shares
+				if shares != nil {
+					classShares.Add(classShares, shares)
+					hasClassShares = true
+				}
+				continue
+			}
+			cover.EntityCommonStockSharesOutstanding = shares
+		case "EntityPublicFloat":
+			cover.EntityPublicFloat = NormalizeValue(value, "xbrli:monetaryItemType", row.Label, scale).Number
+			cover.PublicFloatDate = nullDate(column.Period.End)
+		case "DocumentFiscalYearFocus":
+			cover.DocumentFiscalYearFocus = value
+		case "DocumentFiscalPeriodFocus":
+			cover.DocumentFiscalPeriodFocus = value
+		case "CurrentFiscalYearEndDate":
+			cover.CurrentFiscalYearEndDate = value
+		case "AmendmentFlag":
+			cover.AmendmentFlag = strings.EqualFold(value, "true")
+		case "EntityFilerCategory":
+			cover.EntityFilerCategory = value
+		}
+	}
+	if cover.EntityCommonStockSharesOutstanding == nil && hasClassShares {
+		cover.EntityCommonStockSharesOutstanding = classShares
+	}
+	return cover
+}
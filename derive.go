@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/iterator"
+)
+
+//How a quarterly value was arrived at
+const (
+	MethodReported     = "Reported"
+	MethodYearToDate   = "YearToDateDifference"
+	MethodQuarterlySum = "QuarterlySum"
+)
+
+//Whether a quarterly value covers the quarter itself or the twelve months ending with it
+const (
+	BasisQuarter        = "Quarter"
+	BasisTrailingTwelve = "TTM"
+)
+
+//PeriodValue is a reported duration value of an income or cash flow statement, read back from BigQuery to derive quarters from
+type PeriodValue struct {
+	Statement       string
+	Year            string
+	Quarter         string
+	CIK             string
+	AccessionNumber string
+	Tag             string
+	Item            string
+	Unit            string
+	Currency        string
+	Dimensions      []Dimension
+	PeriodStart     bigquery.NullDate
+	PeriodEnd       bigquery.NullDate
+	PeriodMonths    int
+	FiscalYear      int
+	FiscalQuarter   string
+	NumericValue    *big.Rat
+}
+
+//QuarterlyValue is a discrete three month or trailing twelve month value of a concept. Values that no filing
+//reports directly are flagged Derived and list the accession numbers of the filings they were computed from
+type QuarterlyValue struct {
+	CIK                    string
+	Statement              string
+	Tag                    string
+	Item                   string
+	Unit                   string
+	Currency               string
+	Dimensions             []Dimension
+	IsDefaultContext       bool
+	FiscalYear             int
+	FiscalQuarter          string
+	Basis                  string
+	PeriodStart            bigquery.NullDate
+	PeriodEnd              bigquery.NullDate
+	PeriodMonths           int
+	NumericValue           *big.Rat `bigquery:",nullable"`
+	Derived                bool
+	Method                 string
+	SourceAccessionNumbers []string
+}
+
+//periodValueKey groups the values of a company that describe the same concept under the same dimensions
+func periodValueKey(value PeriodValue) string {
+	return strings.Join([]string{value.CIK, value.Statement, value.Tag, value.Unit, value.Currency, dimensionKey(value.Dimensions)}, "|")
+}
+
+//isNewerReport reports whether a value was filed after another one for the same period, later filings carry
+//any restatement of the figures
+func isNewerReport(value PeriodValue, other PeriodValue) bool {
+	if value.Year+value.Quarter != other.Year+other.Quarter {
+		return value.Year+value.Quarter > other.Year+other.Quarter
+	}
+	return value.AccessionNumber > other.AccessionNumber
+}
+
+//mergeAccessionNumbers lists the source filings of a derived value once each
+func mergeAccessionNumbers(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, accessionNumber := range list {
+			if !seen[accessionNumber] {
+				seen[accessionNumber] = true
+				merged = append(merged, accessionNumber)
+			}
+		}
+	}
+	return merged
+}
+
+//quarterValue builds the quarterly value for a reported value, keeping its concept and dimensions
+func quarterValue(value PeriodValue, basis string) QuarterlyValue {
+	return QuarterlyValue{CIK: value.CIK, Statement: value.Statement, Tag: value.Tag, Item: value.Item, Unit: value.Unit, Currency: value.Currency, Dimensions: value.Dimensions, IsDefaultContext: len(value.Dimensions) == 0, FiscalYear: value.FiscalYear, FiscalQuarter: value.FiscalQuarter, Basis: basis, PeriodStart: value.PeriodStart, PeriodEnd: value.PeriodEnd, PeriodMonths: value.PeriodMonths, NumericValue: value.NumericValue, Method: MethodReported, SourceAccessionNumbers: []string{value.AccessionNumber}}
+}
+
+//isContiguous reports whether a quarter starts right after the previous one ended, allowing for 52/53 week calendars
+func isContiguous(previous QuarterlyValue, next QuarterlyValue) bool {
+	gap := next.PeriodStart.Date.DaysSince(previous.PeriodEnd.Date)
+	return gap >= -6 && gap <= 8
+}
+
+//deriveQuarters computes the discrete quarters and trailing twelve months of one concept from its reported durations
+func deriveQuarters(values []PeriodValue) []QuarterlyValue {
+	//keep the latest report of every period
+	periods := make(map[[2]civil.Date]PeriodValue)
+	for _, value := range values {
+		period := [2]civil.Date{value.PeriodStart.Date, value.PeriodEnd.Date}
+		if existing, ok := periods[period]; !ok || isNewerReport(value, existing) {
+			periods[period] = value
+		}
+	}
+	var reported []PeriodValue
+	for _, value := range periods {
+		reported = append(reported, value)
+	}
+	sort.Slice(reported, func(i, j int) bool {
+		if reported[i].PeriodEnd.Date != reported[j].PeriodEnd.Date {
+			return reported[i].PeriodEnd.Date.Before(reported[j].PeriodEnd.Date)
+		}
+		return reported[i].PeriodMonths < reported[j].PeriodMonths
+	})
+
+	quarters := make(map[civil.Date]QuarterlyValue)
+	for _, value := range reported {
+		if value.PeriodMonths == 3 {
+			quarters[value.PeriodEnd.Date] = quarterValue(value, BasisQuarter)
+		}
+	}
+	//Q2 = 6M - Q1, Q3 = 9M - 6M and Q4 = FY - 9M, using year to date durations that start on the same day
+	for _, value := range reported {
+		if value.PeriodMonths < 6 || value.PeriodMonths > 12 || value.PeriodMonths%3 != 0 {
+			continue
+		}
+		if _, ok := quarters[value.PeriodEnd.Date]; ok {
+			continue
+		}
+		for _, earlier := range reported {
+			if earlier.PeriodStart.Date != value.PeriodStart.Date || earlier.PeriodMonths != value.PeriodMonths-3 {
+				continue
+			}
+			quarter := quarterValue(value, BasisQuarter)
+			quarter.FiscalQuarter = fmt.Sprintf("Q%d", value.PeriodMonths/3)
+			quarter.PeriodStart = nullDate(earlier.PeriodEnd.Date.AddDays(1))
+			quarter.PeriodMonths = 3
+			quarter.NumericValue = new(big.Rat).Sub(value.NumericValue, earlier.NumericValue)
+			quarter.Derived = true
+			quarter.Method = MethodYearToDate
+			quarter.SourceAccessionNumbers = mergeAccessionNumbers([]string{value.AccessionNumber}, []string{earlier.AccessionNumber})
+			quarters[value.PeriodEnd.Date] = quarter
+			break
+		}
+	}
+
+	var ordered []QuarterlyValue
+	for _, quarter := range quarters {
+		ordered = append(ordered, quarter)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].PeriodEnd.Date.Before(ordered[j].PeriodEnd.Date) })
+	annual := make(map[civil.Date]PeriodValue)
+	for _, value := range reported {
+		if value.PeriodMonths == 12 {
+			annual[value.PeriodEnd.Date] = value
+		}
+	}
+
+	quarterlyValues := append([]QuarterlyValue(nil), ordered...)
+	for i, quarter := range ordered {
+		if value, ok := annual[quarter.PeriodEnd.Date]; ok {
+			ttm := quarterValue(value, BasisTrailingTwelve)
+			ttm.FiscalQuarter = quarter.FiscalQuarter
+			quarterlyValues = append(quarterlyValues, ttm)
+			continue
+		}
+		if i < 3 || !isContiguous(ordered[i-3], ordered[i-2]) || !isContiguous(ordered[i-2], ordered[i-1]) || !isContiguous(ordered[i-1], quarter) {
+			continue
+		}
+		ttm := quarter
+		ttm.Basis = BasisTrailingTwelve
+		ttm.PeriodStart = ordered[i-3].PeriodStart
+		ttm.PeriodMonths = 12
+		ttm.NumericValue = new(big.Rat)
+		ttm.Derived = true
+		ttm.Method = MethodQuarterlySum
+		ttm.SourceAccessionNumbers = nil
+		for _, part := range ordered[i-3 : i+1] {
+			ttm.NumericValue.Add(ttm.NumericValue, part.NumericValue)
+			ttm.SourceAccessionNumbers = mergeAccessionNumbers(ttm.SourceAccessionNumbers, part.SourceAccessionNumbers)
+		}
+		quarterlyValues = append(quarterlyValues, ttm)
+	}
+	return quarterlyValues
+}
+
+//DeriveQuarterlyValues turns the three month, year to date and annual durations reported across a company's
+//10-Qs and 10-Ks into discrete quarters (including the Q4 no filing reports) and trailing twelve month values
+func DeriveQuarterlyValues(values []PeriodValue) []QuarterlyValue {
+	groups := make(map[string][]PeriodValue)
+	var keys []string
+	for _, value := range values {
+		if value.NumericValue == nil || !value.PeriodStart.Valid || !value.PeriodEnd.Valid || value.PeriodMonths == 0 {
+			continue
+		}
+		key := periodValueKey(value)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], value)
+	}
+	var quarterlyValues []QuarterlyValue
+	for _, key := range keys {
+		quarterlyValues = append(quarterlyValues, deriveQuarters(groups[key])...)
+	}
+	return quarterlyValues
+}
+
+//periodValuesQuery reads the numeric durations of the income and cash flow statement tables
+const periodValuesQuery = `SELECT '%[2]s' AS Statement, Year, Quarter, CIK, AccessionNumber, Tag, Item, Unit, Currency, Dimensions, PeriodStart, PeriodEnd, PeriodMonths, FiscalYear, FiscalQuarter, NumericValue
+FROM ` + "`%[1]s.SEC.%[2]s`" + `
+WHERE NumericValue IS NOT NULL AND PeriodMonths > 0%[3]s`
+
+//deriveCommand rebuilds the quarterly-values table from what has been loaded into the statement tables
+func deriveCommand(args []string) {
+	flags := flag.NewFlagSet("derive", flag.ExitOnError)
+	cik := flags.String("cik", "", "only derive values for this CIK")
+	flags.Parse(args)
+
+	fmt.Println("Please enter in your Google Cloud project name: ")
+	var projectName string
+	fmt.Scanln(&projectName)
+	ctx := context.Background()
+	bq, err := bigquery.NewClient(ctx, projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bq.Close()
+
+	filter := ""
+	if *cik != "" {
+		filter = " AND CIK = @cik"
+	}
+	var values []PeriodValue
+	for _, statement := range []string{"income-statement", "cash-flow-statement"} {
+		q := bq.Query(fmt.Sprintf(periodValuesQuery, projectName, statement, filter))
+		if *cik != "" {
+			q.Parameters = []bigquery.QueryParameter{{Name: "cik", Value: *cik}}
+		}
+		it, err := q.Read(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for {
+			var value PeriodValue
+			err := it.Next(&value)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			values = append(values, value)
+		}
+	}
+	quarterlyValues := DeriveQuarterlyValues(values)
+	fmt.Println(len(quarterlyValues), "quarterly values derived from", len(values), "reported values")
+
+	//Replace the table, or the company's rows, so reruns don't duplicate values. Rows are written with load jobs as
+	//streamed rows can't be deleted for a while and may be dropped right after a table is recreated
+	quarterlyValuesTable := bq.Dataset("SEC").Table("quarterly-values")
+	quarterlyValuesSchema, _ := bigquery.InferSchema(QuarterlyValue{})
+	disposition := bigquery.WriteTruncate
+	if *cik != "" {
+		if err := createTable(ctx, quarterlyValuesTable, quarterlyValuesSchema); err != nil {
+			fmt.Println(err)
+		}
+		q := bq.Query(fmt.Sprintf("DELETE FROM `%s.SEC.quarterly-values` WHERE CIK = @cik", projectName))
+		q.Parameters = []bigquery.QueryParameter{{Name: "cik", Value: *cik}}
+		if err := runStatement(ctx, q); err != nil {
+			fmt.Println("Can't remove the earlier quarterly values of", *cik)
+			log.Fatal(err)
+		}
+		disposition = bigquery.WriteAppend
+	}
+	if err := loadRows(ctx, quarterlyValuesTable, quarterlyValuesSchema, quarterlyValues, disposition); err != nil {
+		fmt.Println("Can't upload quarterly values")
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+//applePeriod is a revenue value of Apple's fiscal 2019 (a 52 week year ended Sep. 28, 2019) as its filings report it
+func applePeriod(accessionNumber string, indexQuarter string, start civil.Date, end civil.Date, months int, value int64) PeriodValue {
+	return PeriodValue{Statement: "income-statement", Year: "2019", Quarter: indexQuarter, CIK: "320193", AccessionNumber: accessionNumber, Tag: "us-gaap_RevenueFromContractWithCustomerExcludingAssessedTax", Item: "Net sales", Unit: "USD", Currency: "USD", PeriodStart: nullDate(start), PeriodEnd: nullDate(end), PeriodMonths: months, NumericValue: big.NewRat(value, 1)}
+}
+
+func day(year int, month time.Month, d int) civil.Date {
+	return civil.Date{Year: year, Month: month, Day: d}
+}
+
+var (
+	fiscal2019Start = day(2018, time.September, 30)
+	q1End2019       = day(2018, time.December, 29)
+	q2End2019       = day(2019, time.March, 30)
+	q3End2019       = day(2019, time.June, 29)
+	fiscal2019End   = day(2019, time.September, 28)
+)
+
+func TestDeriveQuarters(t *testing.T) {
+	type want struct {
+		basis   string
+		end     civil.Date
+		start   civil.Date
+		value   int64
+		method  string
+		sources int
+	}
+	tests := []struct {
+		name   string
+		values []PeriodValue
+		want   []want
+	}{
+		{
+			name: "year to date differences give Q2, Q3 and the Q4 no filing reports",
+			values: []PeriodValue{
+				applePeriod("0000320193-19-000010", "QTR1", fiscal2019Start, q1End2019, 3, 84310),
+				applePeriod("0000320193-19-000066", "QTR2", fiscal2019Start, q2End2019, 6, 142365),
+				applePeriod("0000320193-19-000076", "QTR3", fiscal2019Start, q3End2019, 9, 196383),
+				applePeriod("0000320193-19-000119", "QTR4", fiscal2019Start, fiscal2019End, 12, 260174),
+			},
+			want: []want{
+				{BasisQuarter, q1End2019, fiscal2019Start, 84310, MethodReported, 1},
+				{BasisQuarter, q2End2019, day(2018, time.December, 30), 58055, MethodYearToDate, 2},
+				{BasisQuarter, q3End2019, day(2019, time.March, 31), 54018, MethodYearToDate, 2},
+				{BasisQuarter, fiscal2019End, day(2019, time.June, 30), 63791, MethodYearToDate, 2},
+				{BasisTrailingTwelve, fiscal2019End, fiscal2019Start, 260174, MethodReported, 1},
+			},
+		},
+		{
+			name: "a reported quarter is kept over the year to date difference",
+			values: []PeriodValue{
+				applePeriod("0000320193-19-000010", "QTR1", fiscal2019Start, q1End2019, 3, 84310),
+				applePeriod("0000320193-19-000066", "QTR2", day(2018, time.December, 30), q2End2019, 3, 58015),
+				applePeriod("0000320193-19-000066", "QTR2", fiscal2019Start, q2End2019, 6, 142365),
+			},
+			want: []want{
+				{BasisQuarter, q1End2019, fiscal2019Start, 84310, MethodReported, 1},
+				{BasisQuarter, q2End2019, day(2018, time.December, 30), 58015, MethodReported, 1},
+			},
+		},
+		{
+			name: "a later filing's restated figure replaces the original",
+			values: []PeriodValue{
+				applePeriod("0000320193-19-000010", "QTR1", fiscal2019Start, q1End2019, 3, 84310),
+				func() PeriodValue {
+					restated := applePeriod("0000320193-20-000010", "QTR1", fiscal2019Start, q1End2019, 3, 84300)
+					restated.Year = "2020"
+					return restated
+				}(),
+			},
+			want: []want{
+				{BasisQuarter, q1End2019, fiscal2019Start, 84300, MethodReported, 1},
+			},
+		},
+		{
+			name: "four contiguous quarters sum to a trailing twelve months when no annual figure is reported",
+			values: []PeriodValue{
+				applePeriod("a1", "QTR1", fiscal2019Start, q1End2019, 3, 84310),
+				applePeriod("a2", "QTR2", day(2018, time.December, 30), q2End2019, 3, 58015),
+				applePeriod("a3", "QTR3", day(2019, time.March, 31), q3End2019, 3, 53809),
+				applePeriod("a4", "QTR4", day(2019, time.June, 30), fiscal2019End, 3, 64040),
+			},
+			want: []want{
+				{BasisQuarter, q1End2019, fiscal2019Start, 84310, MethodReported, 1},
+				{BasisQuarter, q2End2019, day(2018, time.December, 30), 58015, MethodReported, 1},
+				{BasisQuarter, q3End2019, day(2019, time.March, 31), 53809, MethodReported, 1},
+				{BasisQuarter, fiscal2019End, day(2019, time.June, 30), 64040, MethodReported, 1},
+				{BasisTrailingTwelve, fiscal2019End, fiscal2019Start, 260174, MethodQuarterlySum, 4},
+			},
+		},
+		{
+			name: "no trailing twelve months across a missing quarter",
+			values: []PeriodValue{
+				applePeriod("a1", "QTR1", fiscal2019Start, q1End2019, 3, 84310),
+				applePeriod("a3", "QTR3", day(2019, time.March, 31), q3End2019, 3, 53809),
+				applePeriod("a4", "QTR4", day(2019, time.June, 30), fiscal2019End, 3, 64040),
+				applePeriod("a5", "QTR1", fiscal2019End.AddDays(1), day(2019, time.December, 28), 3, 91819),
+			},
+			want: []want{
+				{BasisQuarter, q1End2019, fiscal2019Start, 84310, MethodReported, 1},
+				{BasisQuarter, q3End2019, day(2019, time.March, 31), 53809, MethodReported, 1},
+				{BasisQuarter, fiscal2019End, day(2019, time.June, 30), 64040, MethodReported, 1},
+				{BasisQuarter, day(2019, time.December, 28), fiscal2019End.AddDays(1), 91819, MethodReported, 1},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := deriveQuarters(test.values)
+			if len(got) != len(test.want) {
+				t.Fatalf("deriveQuarters() returned %d values, want %d: %+v", len(got), len(test.want), got)
+			}
+			for i, w := range test.want {
+				g := got[i]
+				if g.Basis != w.basis || g.PeriodEnd.Date != w.end || g.PeriodStart.Date != w.start || g.Method != w.method || len(g.SourceAccessionNumbers) != w.sources {
+					t.Errorf("value %d = %s %s..%s %s from %v, want %s %s..%s %s from %d filings", i, g.Basis, g.PeriodStart.Date, g.PeriodEnd.Date, g.Method, g.SourceAccessionNumbers, w.basis, w.start, w.end, w.method, w.sources)
+				}
+				if g.NumericValue.Cmp(big.NewRat(w.value, 1)) != 0 {
+					t.Errorf("value %d = %s, want %d", i, g.NumericValue.RatString(), w.value)
+				}
+				if g.Derived != (w.method != MethodReported) {
+					t.Errorf("value %d Derived = %v with method %s", i, g.Derived, g.Method)
+				}
+			}
+		})
+	}
+}
+
+func TestDeriveQuarterlyValuesGroupsByDimensions(t *testing.T) {
+	total := applePeriod("a1", "QTR1", fiscal2019Start, q1End2019, 3, 84310)
+	iphone := applePeriod("a1", "QTR1", fiscal2019Start, q1End2019, 3, 51982)
+	iphone.Dimensions = []Dimension{{Axis: "srt_ProductOrServiceAxis", Member: "us-gaap_IPhoneMember"}}
+	undated := applePeriod("a1", "QTR1", fiscal2019Start, q1End2019, 3, 1)
+	undated.PeriodStart.Valid = false
+
+	got := DeriveQuarterlyValues([]PeriodValue{total, iphone, undated})
+	if len(got) != 2 {
+		t.Fatalf("DeriveQuarterlyValues() returned %d values, want 2: %+v", len(got), got)
+	}
+	if !got[0].IsDefaultContext || got[0].NumericValue.Cmp(big.NewRat(84310, 1)) != 0 {
+		t.Errorf("total = %+v, want the default context value 84310", got[0])
+	}
+	if got[1].IsDefaultContext || got[1].NumericValue.Cmp(big.NewRat(51982, 1)) != 0 {
+		t.Errorf("iPhone = %+v, want the dimensioned value 51982", got[1])
+	}
+}
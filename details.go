@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math/big"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/anaskhan96/soup"
+)
+
+//FactItem is a single value of any R page in long format, one row per line item and column
+type FactItem struct {
+	Year             string
+	Quarter          string
+	CIK              string
+	FiscalYear       int
+	FiscalQuarter    string
+	AccessionNumber  string
+	ReportName       string
+	Role             string
+	ParentRole       string
+	Title            string
+	Column           string
+	Date             string
+	Duration         string
+	PeriodStart      bigquery.NullDate
+	PeriodEnd        bigquery.NullDate
+	PeriodMonths     int
+	Item             string
+	Value            string
+	NumericValue     *big.Rat `bigquery:",nullable"`
+	Unit             string
+	Currency         string
+	Scale            int64
+	Dimensions       []Dimension
+	IsDefaultContext bool
+	Abstract         string
+	Tag              string
+	Concept          string
+	Definition       string
+	DataType         string
+	BalanceType      string
+	PeriodType       string
+}
+
+//ParseDetailsReport loads any R page table, such as the Details reports behind debt maturities, lease schedules or
+//tax reconciliations, into long-format facts tagged with the report's role so no bespoke parser is needed per note
+func ParseDetailsReport(detailsReport []byte, report CatalogReport, year string, qtr string, cik string, accessionNumber string) []FactItem {
+	doc := soup.HTMLParse(string(detailsReport))
+	title, columns, rows := parseReportRows(doc)
+	scale := ParseStatementScale(title)
+
+	details := make(map[string]conceptDetails)
+	var factRows []FactItem
+	for _, row := range rows {
+		if _, ok := details[row.Tag]; !ok {
+			details[row.Tag] = parseConceptDetails(doc, row.Tag)
+		}
+		detail := details[row.Tag]
+		for i, column := range columns {
+			if i >= len(row.Cells) || row.Cells[i] == "" {
+				continue
+			}
+			value := NormalizeValue(row.Cells[i], detail.DataType, row.Label, scale)
+			factRow := FactItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, ReportName: report.Name, Role: report.Role, ParentRole: report.ParentRole, Title: title, Column: column.Label(), Date: column.Date, Duration: column.Duration, PeriodStart: nullDate(column.Period.Start), PeriodEnd: nullDate(column.Period.End), PeriodMonths: column.Period.Months, Item: row.Label, Value: row.Cells[i], NumericValue: value.Number, Unit: value.Unit, Currency: value.Currency, Scale: value.Scale, Dimensions: row.Dimensions, IsDefaultContext: len(row.Dimensions) == 0, Abstract: row.Abstract, Tag: row.Tag, Concept: xbrlConcept(row.Tag), Definition: detail.Definition, DataType: detail.DataType, BalanceType: detail.BalanceType, PeriodType: detail.PeriodType}
+			factRows = append(factRows, factRow)
+		}
+	}
+	return factRows
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+//Dimension is a single axis/member pair qualifying a fact, e.g. srt:ProductOrServiceAxis = us-gaap:ProductMember
+//Typed dimensions have no member element, so Member holds the typed value shown in the statement
+type Dimension struct {
+	Axis        string
+	Member      string
+	MemberLabel string
+	Typed       bool
+}
+
+//dimensionContext keeps track of the axis and member rows seen while walking down a statement table
+//so every line item can be tagged with the dimensions it was reported under
+type dimensionContext struct {
+	dimensions   []Dimension
+	pendingAxis  string
+	itemsInScope bool
+}
+
+//xbrlConcept turns the defref id used in the statement html (defref_us-gaap_ProductMember) into a QName (us-gaap:ProductMember)
+func xbrlConcept(xbrlTag string) string {
+	concept := strings.TrimPrefix(xbrlTag, "defref_")
+	return strings.Replace(concept, "_", ":", 1)
+}
+
+//dimensionKey identifies a set of dimensions regardless of the order they were reported in ("" for the default context)
+func dimensionKey(dimensions []Dimension) string {
+	pairs := make([]string, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		pairs = append(pairs, dimension.Axis+"="+dimension.Member)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+//isMemberTag reports whether the tag is a dimension member or domain rather than a line item
+func isMemberTag(xbrlTag string) bool {
+	return strings.HasSuffix(xbrlTag, "Member") || strings.HasSuffix(xbrlTag, "Domain")
+}
+
+//axis records an axis row. Axis rows directly below a member nest inside it, otherwise they start a new breakdown
+func (d *dimensionContext) axis(xbrlTag string) {
+	axis := xbrlConcept(xbrlTag)
+	if d.itemsInScope {
+		d.dimensions = nil
+		d.itemsInScope = false
+	}
+	for i, dimension := range d.dimensions {
+		if dimension.Axis == axis {
+			d.dimensions = d.dimensions[:i]
+			break
+		}
+	}
+	d.pendingAxis = axis
+}
+
+//expectingMember reports whether an axis row was seen that has not been given a member yet
+func (d *dimensionContext) expectingMember() bool {
+	return d.pendingAxis != ""
+}
+
+//member records a member row. A member without an axis row above it is a sibling of the last member seen
+func (d *dimensionContext) member(member string, label string, typed bool) {
+	dimension := Dimension{Axis: d.pendingAxis, Member: member, MemberLabel: strings.TrimSpace(label), Typed: typed}
+	if !typed {
+		dimension.Member = xbrlConcept(member)
+	}
+	if dimension.Axis == "" && len(d.dimensions) > 0 {
+		dimension.Axis = d.dimensions[len(d.dimensions)-1].Axis
+		d.dimensions = d.dimensions[:len(d.dimensions)-1]
+	}
+	d.dimensions = append(d.dimensions, dimension)
+	d.pendingAxis = ""
+	d.itemsInScope = false
+}
+
+//lineItem returns the dimensions that apply to the line item on the current row
+func (d *dimensionContext) lineItem() []Dimension {
+	d.itemsInScope = true
+	dimensions := make([]Dimension, len(d.dimensions))
+	copy(dimensions, d.dimensions)
+	return dimensions
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+//SubmissionDocument is one of the documents in a filing's complete submission text file (the .txt the index links to)
+type SubmissionDocument struct {
+	Type        string
+	Sequence    string
+	Filename    string
+	Description string
+	Text        string
+}
+
+var (
+	documentPattern      = regexp.MustCompile(`(?s)<DOCUMENT>(.*?)</DOCUMENT>`)
+	documentFieldPattern = regexp.MustCompile(`(?m)^<(TYPE|SEQUENCE|FILENAME|DESCRIPTION)>(.*)$`)
+	documentTextPattern  = regexp.MustCompile(`(?s)<TEXT>(.*?)</TEXT>`)
+	xmlTextPattern       = regexp.MustCompile(`(?s)<XML>(.*?)</XML>`)
+)
+
+//SubmissionHeaderText is the SEC header at the top of a complete submission text file, readable with ParseSECHeader
+func SubmissionHeaderText(submission []byte) []byte {
+	text := string(submission)
+	if end := strings.Index(text, "</SEC-HEADER>"); end >= 0 {
+		text = text[:end]
+	} else if end := strings.Index(text, "<DOCUMENT>"); end >= 0 {
+		text = text[:end]
+	}
+	return []byte(text)
+}
+
+//ParseSubmissionDocuments splits a complete submission text file into its documents
+func ParseSubmissionDocuments(submission []byte) []SubmissionDocument {
+	var documents []SubmissionDocument
+	for _, match := range documentPattern.FindAllStringSubmatch(string(submission), -1) {
+		var document SubmissionDocument
+		body := match[1]
+		if text := documentTextPattern.FindStringIndex(body); text != nil {
+			document.Text = documentTextPattern.FindStringSubmatch(body)[1]
+			body = body[:text[0]]
+		}
+		for _, field := range documentFieldPattern.FindAllStringSubmatch(body, -1) {
+			value := strings.TrimSpace(field[2])
+			switch field[1] {
+			case "TYPE":
+				document.Type = value
+			case "SEQUENCE":
+				document.Sequence = value
+			case "FILENAME":
+				document.Filename = value
+			case "DESCRIPTION":
+				document.Description = value
+			}
+		}
+		documents = append(documents, document)
+	}
+	return documents
+}
+
+//XML is the xml a document wraps in <XML> tags, such as an ownership document or a 13F information table
+func (d SubmissionDocument) XML() string {
+	if match := xmlTextPattern.FindStringSubmatch(d.Text); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return strings.TrimSpace(d.Text)
+}
+
+var htmlDocumentPattern = regexp.MustCompile(`(?i)<(html|body|div|p|table)[\s>]`)
+
+//PlainText is the text of a document with its html, if any, flattened to a line per paragraph or table row. Plain
+//text documents, as older filings are, keep their layout
+func (d SubmissionDocument) PlainText() string {
+	if !htmlDocumentPattern.MatchString(d.Text) {
+		return strings.TrimSpace(d.Text)
+	}
+	node, err := html.Parse(strings.NewReader(d.Text))
+	if err != nil {
+		return strings.TrimSpace(d.Text)
+	}
+	return noteText(node)
+}
+
+//decodeSubmissionXML reads the xml of a document, leniently about declared charsets as some filers use ISO-8859-1
+func decodeSubmissionXML(text string, v interface{}) error {
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+	return decoder.Decode(v)
+}
+
+//SubmissionURL is the complete submission text file of a filing in the EDGAR archives
+func (item FilingWorkItem) SubmissionURL() string {
+	return "https://www.sec.gov/Archives/" + item.FilingLoc
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/anaskhan96/soup"
+)
+
+//Roll-forward row types of the statement of stockholders' equity
+const (
+	EquityBeginningBalance = "Beginning"
+	EquityChange           = "Change"
+	EquityEndingBalance    = "Ending"
+)
+
+//equityBalanceTags are the concepts filers use for the balance rows of an equity roll-forward
+var equityBalanceTags = []string{
+	"StockholdersEquity",
+	"StockholdersEquityIncludingPortionAttributableToNoncontrollingInterest",
+	"PartnersCapital",
+	"PartnersCapitalIncludingPortionAttributableToNoncontrollingInterest",
+	"PartnersCapitalAccountUnits",
+	"MembersEquity",
+	"SharesOutstanding",
+	"CommonStockSharesOutstanding",
+	"SharesIssued",
+	"TreasuryStockShares",
+}
+
+type StockholdersEquityItem struct {
+	Year             string
+	Quarter          string
+	CIK              string
+	AccessionNumber  string
+	FiscalYear       int
+	FiscalQuarter    string
+	Title            string
+	Component        string
+	IsTotal          bool
+	RowType          string
+	BalanceDate      bigquery.NullDate
+	PeriodStart      bigquery.NullDate
+	PeriodEnd        bigquery.NullDate
+	Item             string
+	Value            string
+	NumericValue     *big.Rat `bigquery:",nullable"`
+	Unit             string
+	Currency         string
+	Scale            int64
+	Dimensions       []Dimension
+	IsDefaultContext bool
+	Abstract         string
+	Tag              string
+	Definition       string
+	DataType         string
+	BalanceType      string
+	PeriodType       string
+}
+
+//isEquityBalanceRow reports whether a row is an opening or closing balance rather than a change in equity
+func isEquityBalanceRow(row reportRow) bool {
+	for _, tag := range equityBalanceTags {
+		if strings.HasSuffix(row.Tag, "_"+tag) {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(row.Label), "balance")
+}
+
+//equityRollForward tracks one roll-forward (amounts or shares) while walking down the statement
+type equityRollForward struct {
+	beginning     []StockholdersEquityItem
+	beginningDate civil.Date
+	changes       []StockholdersEquityItem
+}
+
+//close stamps the period on the rows of a finished roll-forward and starts the next one from its ending balance
+func (r *equityRollForward) close(ending []StockholdersEquityItem, endingDate civil.Date) []StockholdersEquityItem {
+	var closed []StockholdersEquityItem
+	closed = append(closed, r.beginning...)
+	closed = append(closed, r.changes...)
+	closed = append(closed, ending...)
+	start := bigquery.NullDate{}
+	if r.beginningDate.IsValid() {
+		start = nullDate(r.beginningDate.AddDays(1))
+	}
+	for i := range closed {
+		closed[i].PeriodStart = start
+		closed[i].PeriodEnd = nullDate(endingDate)
+	}
+
+	r.beginning = nil
+	for _, item := range ending {
+		item.RowType = EquityBeginningBalance
+		item.PeriodStart, item.PeriodEnd = bigquery.NullDate{}, bigquery.NullDate{}
+		r.beginning = append(r.beginning, item)
+	}
+	r.beginningDate = endingDate
+	r.changes = nil
+	return closed
+}
+
+//ParseStockholdersEquityStatement parses a statement of stockholders'/shareholders' equity or partners' capital.
+//Equity components run across the columns and the roll-forward runs down the rows, so every row is emitted once per
+//component as a beginning balance, change or ending balance for the period between two balance dates
+func ParseStockholdersEquityStatement(equityStatement []byte, year string, qtr string, cik string, accessionNumber string) []StockholdersEquityItem {
+	doc := soup.HTMLParse(string(equityStatement))
+	title, columns, rows := parseReportRows(doc)
+	scale := ParseStatementScale(title)
+
+	details := make(map[string]conceptDetails)
+	rollForwards := make(map[string]*equityRollForward)
+	var equityRows []StockholdersEquityItem
+	for _, row := range rows {
+		if _, ok := details[row.Tag]; !ok {
+			details[row.Tag] = parseConceptDetails(doc, row.Tag)
+		}
+		detail := details[row.Tag]
+		isBalance := isEquityBalanceRow(row)
+		balanceDate, hasBalanceDate := parsePeriodDate(row.Label)
+
+		var items []StockholdersEquityItem
+		for i, column := range columns {
+			if i >= len(row.Cells) {
+				break
+			}
+			value := NormalizeValue(row.Cells[i], detail.DataType, row.Label, scale)
+			if value.Number == nil {
+				continue
+			}
+			component := column.Label()
+			item := StockholdersEquityItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, Title: title, Component: component, IsTotal: component == "" || strings.EqualFold(component, "Total"), RowType: EquityChange, Item: row.Label, Value: row.Cells[i], NumericValue: value.Number, Unit: value.Unit, Currency: value.Currency, Scale: value.Scale, Dimensions: row.Dimensions, IsDefaultContext: len(row.Dimensions) == 0, Abstract: row.Abstract, Tag: row.Tag, Definition: detail.Definition, DataType: detail.DataType, BalanceType: detail.BalanceType, PeriodType: detail.PeriodType}
+			if isBalance && hasBalanceDate {
+				item.BalanceDate = nullDate(balanceDate)
+			}
+			items = append(items, item)
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		//amounts and share counts are rolled forward separately in the same table
+		unit := items[0].Unit
+		rollForward, ok := rollForwards[unit]
+		if !ok {
+			rollForward = &equityRollForward{}
+			rollForwards[unit] = rollForward
+		}
+		switch {
+		case isBalance && len(rollForward.changes) == 0:
+			for i := range items {
+				items[i].RowType = EquityBeginningBalance
+			}
+			rollForward.beginning = items
+			rollForward.beginningDate = balanceDate
+		case isBalance:
+			for i := range items {
+				items[i].RowType = EquityEndingBalance
+			}
+			equityRows = append(equityRows, rollForward.close(items, balanceDate)...)
+		default:
+			rollForward.changes = append(rollForward.changes, items...)
+		}
+	}
+
+	//a roll-forward without a closing balance row still keeps its rows, just without a period end
+	for _, rollForward := range rollForwards {
+		if len(rollForward.changes) > 0 {
+			equityRows = append(equityRows, rollForward.close(nil, civil.Date{})...)
+		}
+	}
+	return equityRows
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//isEventForm reports whether a form is a current report of material events or an amendment to one
+func isEventForm(form string) bool {
+	return form == "8-K" || form == "8-K/A"
+}
+
+//eventItems are the 8-K items in force since August 2004 and their titles as given in the SEC header
+var eventItems = map[string]string{
+	"1.01": "Entry into a Material Definitive Agreement",
+	"1.02": "Termination of a Material Definitive Agreement",
+	"1.03": "Bankruptcy or Receivership",
+	"1.04": "Mine Safety - Reporting of Shutdowns and Patterns of Violations",
+	"1.05": "Material Cybersecurity Incidents",
+	"2.01": "Completion of Acquisition or Disposition of Assets",
+	"2.02": "Results of Operations and Financial Condition",
+	"2.03": "Creation of a Direct Financial Obligation or an Obligation under an Off-Balance Sheet Arrangement of a Registrant",
+	"2.04": "Triggering Events That Accelerate or Increase a Direct Financial Obligation or an Obligation under an Off-Balance Sheet Arrangement",
+	"2.05": "Costs Associated with Exit or Disposal Activities",
+	"2.06": "Material Impairments",
+	"3.01": "Notice of Delisting or Failure to Satisfy a Continued Listing Rule or Standard; Transfer of Listing",
+	"3.02": "Unregistered Sales of Equity Securities",
+	"3.03": "Material Modification to Rights of Security Holders",
+	"4.01": "Changes in Registrant's Certifying Accountant",
+	"4.02": "Non-Reliance on Previously Issued Financial Statements or a Related Audit Report or Completed Interim Review",
+	"5.01": "Changes in Control of Registrant",
+	"5.02": "Departure of Directors or Certain Officers; Election of Directors; Appointment of Certain Officers: Compensatory Arrangements of Certain Officers",
+	"5.03": "Amendments to Articles of Incorporation or Bylaws; Change in Fiscal Year",
+	"5.04": "Temporary Suspension of Trading Under Registrant's Employee Benefit Plans",
+	"5.05": "Amendments to the Registrant's Code of Ethics, or Waiver of a Provision of the Code of Ethics",
+	"5.06": "Change in Shell Company Status",
+	"5.07": "Submission of Matters to a Vote of Security Holders",
+	"5.08": "Shareholder Director Nominations",
+	"6.01": "ABS Informational and Computational Material",
+	"6.02": "Change of Servicer or Trustee",
+	"6.03": "Change in Credit Enhancement or Other External Support",
+	"6.04": "Failure to Make a Required Distribution",
+	"6.05": "Securities Act Updating Disclosure",
+	"6.06": "Static Pool",
+	"7.01": "Regulation FD Disclosure",
+	"8.01": "Other Events",
+	"9.01": "Financial Statements and Exhibits",
+}
+
+var (
+	eventItemPattern     = regexp.MustCompile(`(?i)\bitem\s*(\d)\s*\.\s*(\d{2})\b`)
+	eventItemTitlePrefix = regexp.MustCompile(`(?i)^item\s*\d\.\d{2}\s*`)
+	titleCharPattern     = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+//normalizeItemTitle compares item titles on their letters and digits only, filers and the header differ in punctuation
+func normalizeItemTitle(title string) string {
+	return strings.TrimSpace(titleCharPattern.ReplaceAllString(strings.ToLower(title), " "))
+}
+
+//eventItemNumber finds the item number of an ITEM INFORMATION title of the SEC header. Long titles are cut short in
+//some headers so a title matches when either is the start of the other
+func eventItemNumber(title string) (string, bool) {
+	if match := eventItemPattern.FindStringSubmatch(title); match != nil {
+		number := match[1] + "." + match[2]
+		_, ok := eventItems[number]
+		return number, ok
+	}
+	header := normalizeItemTitle(eventItemTitlePrefix.ReplaceAllString(title, ""))
+	if header == "" {
+		return "", false
+	}
+	for number, name := range eventItems {
+		known := normalizeItemTitle(name)
+		if strings.HasPrefix(known, header) || strings.HasPrefix(header, known) {
+			return number, true
+		}
+	}
+	return "", false
+}
+
+//EventFiling is an 8-K, one row per filing. Items are the item numbers reported in the SEC header (e.g. 2.02 for an
+//earnings release), or those found in the document when the header has none. EventDate is the date of the earliest
+//event reported
+type EventFiling struct {
+	Year                 string
+	Quarter              string
+	CIK                  string
+	CompanyName          string
+	AccessionNumber      string
+	Form                 string
+	FilingDate           bigquery.NullDate
+	EventDate            bigquery.NullDate
+	AcceptanceDateTime   bigquery.NullTimestamp
+	Items                []string
+	ItemDescriptions     []string
+	HeaderItems          []string
+	DocumentItems        []string
+	PrimaryDocument      string
+	Text                 string
+	PressReleaseDocument string
+	PressRelease         string
+}
+
+//pressReleaseDocument finds the EX-99.1 exhibit of an 8-K, falling back on the first EX-99 exhibit for filers who
+//number it otherwise
+func pressReleaseDocument(documents []SubmissionDocument) (SubmissionDocument, bool) {
+	var fallback *SubmissionDocument
+	for i, document := range documents {
+		exhibit := strings.ToUpper(document.Type)
+		switch {
+		case exhibit == "EX-99.1" || exhibit == "EX-99.01" || exhibit == "EX-99.(1)":
+			return document, true
+		case strings.HasPrefix(exhibit, "EX-99") && fallback == nil:
+			fallback = &documents[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return SubmissionDocument{}, false
+}
+
+//ParseEventFiling reads an 8-K's complete submission text file into its events row. Item numbers are taken from the
+//ITEM INFORMATION lines of the header, or from the "Item 2.02" mentions of the primary document as older headers
+//don't list them
+func ParseEventFiling(submission []byte, year string, qtr string, cik string, companyName string, accessionNumber string, form string, dateFiled string) EventFiling {
+	header := ParseSECHeader(SubmissionHeaderText(submission))
+	event := EventFiling{Year: year, Quarter: qtr, CIK: normalizeCIK(cik), CompanyName: companyName, AccessionNumber: accessionNumber, Form: form, FilingDate: xmlDate(dateFiled)}
+	if header.CompanyName != "" {
+		event.CompanyName = header.CompanyName
+	}
+	if header.PeriodOfReport.IsValid() {
+		event.EventDate = nullDate(header.PeriodOfReport)
+	}
+	if !header.AcceptanceDateTime.IsZero() {
+		event.AcceptanceDateTime = bigquery.NullTimestamp{Timestamp: header.AcceptanceDateTime, Valid: true}
+	}
+
+	documents := ParseSubmissionDocuments(submission)
+	//the primary document is the one of the form's type, which is listed first
+	for i, document := range documents {
+		if document.Type == form || (i == 0 && !strings.HasPrefix(document.Type, "EX-")) {
+			event.PrimaryDocument = document.Filename
+			event.Text = document.PlainText()
+		}
+		if document.Type == form {
+			break
+		}
+	}
+	if exhibit, ok := pressReleaseDocument(documents); ok {
+		event.PressReleaseDocument = exhibit.Filename
+		event.PressRelease = exhibit.PlainText()
+	}
+
+	found := make(map[string]bool)
+	for _, title := range header.Items {
+		if number, ok := eventItemNumber(title); ok && !found[number] {
+			found[number] = true
+			event.HeaderItems = append(event.HeaderItems, number)
+		}
+	}
+	inDocument := make(map[string]bool)
+	for _, match := range eventItemPattern.FindAllStringSubmatch(event.Text, -1) {
+		number := match[1] + "." + match[2]
+		if _, ok := eventItems[number]; ok && !inDocument[number] {
+			inDocument[number] = true
+			event.DocumentItems = append(event.DocumentItems, number)
+		}
+	}
+	sort.Strings(event.DocumentItems)
+	//the body also refers to items it doesn't report ("as described under Item 1.01"), so its items are only used
+	//when the header lists none
+	event.Items = append(event.Items, event.HeaderItems...)
+	if len(event.Items) == 0 {
+		event.Items = append(event.Items, event.DocumentItems...)
+	}
+	sort.Strings(event.Items)
+	for _, number := range event.Items {
+		event.ItemDescriptions = append(event.ItemDescriptions, eventItems[number])
+	}
+	return event
+}
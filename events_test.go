@@ -0,0 +1,165 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/civil"
+)
+
+//apple8KSubmission is the complete submission text file of an earnings release 8-K, the results in the primary
+//document and the press release in its EX-99.1 exhibit
+const apple8KSubmission = `
+<SEC-DOCUMENT>0000320193-23-000077.txt : 20230803
+<SEC-HEADER>0000320193-23-000077.hdr.sgml : 20230803
+<ACCEPTANCE-DATETIME>20230803163026
+ACCESSION NUMBER:		0000320193-23-000077
+CONFORMED SUBMISSION TYPE:	8-K
+PUBLIC DOCUMENT COUNT:		14
+CONFORMED PERIOD OF REPORT:	20230803
+ITEM INFORMATION:		Results of Operations and Financial Condition
+ITEM INFORMATION:		Financial Statements and Exhibits
+FILED AS OF DATE:		20230803
+FILER:
+	COMPANY DATA:	
+		COMPANY CONFORMED NAME:			Apple Inc.
+		CENTRAL INDEX KEY:			0000320193
+</SEC-HEADER>
+<DOCUMENT>
+<TYPE>8-K
+<SEQUENCE>1
+<FILENAME>aapl-20230803.htm
+<DESCRIPTION>8-K
+<TEXT>
+<html><body><div style="display:none"><ix:header><ix:hidden>hidden fact</ix:hidden></ix:header></div>
+<p>UNITED STATES</p><p><b>Item&#160;2.02&#160;&#160;&#160;&#160;Results of Operations</b></p><p>On August 3, 2023, Apple announced results.</p>
+<p>Item 9.01 Financial Statements and Exhibits.</p><table><tr><td>99.1</td><td>Press release</td></tr></table>
+</body></html>
+</TEXT>
+</DOCUMENT>
+<DOCUMENT>
+<TYPE>EX-99.1
+<SEQUENCE>2
+<FILENAME>a8-kex991q3202306243.htm
+<DESCRIPTION>EX-99.1
+<TEXT>
+<html><body><p>Apple reports third quarter results</p><p>Revenue of $81.8 billion</p></body></html>
+</TEXT>
+</DOCUMENT>
+</SEC-DOCUMENT>
+`
+
+func TestEventItemNumber(t *testing.T) {
+	tests := []struct {
+		title  string
+		number string
+		ok     bool
+	}{
+		{"Results of Operations and Financial Condition", "2.02", true},
+		{"Financial Statements and Exhibits", "9.01", true},
+		{"Item 5.02 Departure of Directors", "5.02", true},
+		{"ITEM 2.02: Results of Operations", "2.02", true},
+		{"Departure of Directors or Certain Officers; Election of Directors; Appointment of Certain Officers: Compensatory Arrangements of Certain Officers and more", "5.02", true},
+		{"Departure of Directors or Certain Officers; Election of", "5.02", true},
+		{"Regulation FD Disclosure.", "7.01", true},
+		{"Item 9.99 Unknown", "9.99", false},
+		{"Changes in Fiscal Year Only", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		number, ok := eventItemNumber(test.title)
+		if number != test.number || ok != test.ok {
+			t.Errorf("eventItemNumber(%q) = %q %v, want %q %v", test.title, number, ok, test.number, test.ok)
+		}
+	}
+}
+
+func TestParseEventFiling(t *testing.T) {
+	event := ParseEventFiling([]byte(apple8KSubmission), "2023", "QTR3", "0000320193", "APPLE INC", "0000320193-23-000077", "8-K", "2023-08-03")
+	if event.CIK != "320193" || event.CompanyName != "Apple Inc." || event.PrimaryDocument != "aapl-20230803.htm" {
+		t.Errorf("event = %+v", event)
+	}
+	if event.EventDate != nullDate(civil.Date{Year: 2023, Month: 8, Day: 3}) || !event.AcceptanceDateTime.Valid {
+		t.Errorf("EventDate = %v, AcceptanceDateTime = %v", event.EventDate, event.AcceptanceDateTime)
+	}
+	if want := []string{"2.02", "9.01"}; !reflect.DeepEqual(event.Items, want) || !reflect.DeepEqual(event.HeaderItems, want) || !reflect.DeepEqual(event.DocumentItems, want) {
+		t.Errorf("Items = %v, HeaderItems = %v, DocumentItems = %v, want %v", event.Items, event.HeaderItems, event.DocumentItems, want)
+	}
+	if want := []string{eventItems["2.02"], eventItems["9.01"]}; !reflect.DeepEqual(event.ItemDescriptions, want) {
+		t.Errorf("ItemDescriptions = %q, want %q", event.ItemDescriptions, want)
+	}
+	if !strings.Contains(event.Text, "Apple announced results") || strings.Contains(event.Text, "hidden fact") {
+		t.Errorf("Text = %q", event.Text)
+	}
+	if event.PressReleaseDocument != "a8-kex991q3202306243.htm" || !strings.Contains(event.PressRelease, "Revenue of $81.8 billion") {
+		t.Errorf("PressReleaseDocument = %q, PressRelease = %q", event.PressReleaseDocument, event.PressRelease)
+	}
+}
+
+func TestParseEventFilingDocumentItems(t *testing.T) {
+	//older headers don't list the items, so the ones the document mentions are used
+	var lines []string
+	for _, line := range strings.Split(apple8KSubmission, "\n") {
+		if !strings.HasPrefix(line, "ITEM INFORMATION:") {
+			lines = append(lines, line)
+		}
+	}
+	submission := strings.Replace(strings.Join(lines, "\n"), "announced results.", "announced results, as described under Item 1.01.", 1)
+	event := ParseEventFiling([]byte(submission), "2023", "QTR3", "320193", "Apple Inc.", "0000320193-23-000077", "8-K", "2023-08-03")
+	if len(event.HeaderItems) != 0 {
+		t.Errorf("HeaderItems = %v, want none", event.HeaderItems)
+	}
+	if want := []string{"1.01", "2.02", "9.01"}; !reflect.DeepEqual(event.Items, want) || !reflect.DeepEqual(event.DocumentItems, want) {
+		t.Errorf("Items = %v, DocumentItems = %v, want %v", event.Items, event.DocumentItems, want)
+	}
+
+	//with the header listing items, the items the document only refers to aren't reported
+	event = ParseEventFiling([]byte(strings.Replace(apple8KSubmission, "announced results.", "announced results, as described under Item 1.01.", 1)), "2023", "QTR3", "320193", "Apple Inc.", "0000320193-23-000077", "8-K", "2023-08-03")
+	if want := []string{"2.02", "9.01"}; !reflect.DeepEqual(event.Items, want) {
+		t.Errorf("Items = %v, want the header's %v", event.Items, want)
+	}
+	if want := []string{"1.01", "2.02", "9.01"}; !reflect.DeepEqual(event.DocumentItems, want) {
+		t.Errorf("DocumentItems = %v, want %v", event.DocumentItems, want)
+	}
+}
+
+func TestPressReleaseDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		documents []SubmissionDocument
+		filename  string
+		ok        bool
+	}{
+		{
+			name:      "EX-99.1 over an earlier EX-99 exhibit",
+			documents: []SubmissionDocument{{Type: "8-K", Filename: "form8k.htm"}, {Type: "EX-99.2", Filename: "ex992.htm"}, {Type: "EX-99.1", Filename: "ex991.htm"}},
+			filename:  "ex991.htm",
+			ok:        true,
+		},
+		{
+			name:      "EX-99.01 numbering",
+			documents: []SubmissionDocument{{Type: "8-K", Filename: "form8k.htm"}, {Type: "ex-99.01", Filename: "ex9901.htm"}},
+			filename:  "ex9901.htm",
+			ok:        true,
+		},
+		{
+			name:      "first EX-99 exhibit when there's no EX-99.1",
+			documents: []SubmissionDocument{{Type: "8-K", Filename: "form8k.htm"}, {Type: "EX-10.1", Filename: "ex101.htm"}, {Type: "EX-99", Filename: "ex99.htm"}, {Type: "EX-99.2", Filename: "ex992.htm"}},
+			filename:  "ex99.htm",
+			ok:        true,
+		},
+		{
+			name:      "no press release",
+			documents: []SubmissionDocument{{Type: "8-K", Filename: "form8k.htm"}, {Type: "EX-10.1", Filename: "ex101.htm"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document, ok := pressReleaseDocument(test.documents)
+			if document.Filename != test.filename || ok != test.ok {
+				t.Errorf("got %q %v, want %q %v", document.Filename, ok, test.filename, test.ok)
+			}
+		})
+	}
+}
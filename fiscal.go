@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+//fiscalYearEndTolerance is how many days a 52/53 week fiscal year can end after its nominal month end date
+const fiscalYearEndTolerance = 7
+
+//FiscalCalendar assigns fiscal years and quarters to the periods a filing reports, based on the company's
+//fiscal year end and calibrated against the fiscal year and period the filing says it covers
+type FiscalCalendar struct {
+	YearEndMonth      time.Month
+	YearEndDay        int
+	yearOffset        int
+	documentPeriodEnd civil.Date
+	documentPeriod    string
+}
+
+//parseFiscalYearEnd reads the fiscal year end as the dei fact ("--09-26") or the SEC header field ("0926")
+func parseFiscalYearEnd(value string) (time.Month, int, bool) {
+	value = strings.Replace(strings.TrimPrefix(strings.TrimSpace(value), "--"), "-", "", 1)
+	if len(value) != 4 {
+		return 0, 0, false
+	}
+	month, err := strconv.Atoi(value[:2])
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, false
+	}
+	day, err := strconv.Atoi(value[2:])
+	if err != nil || day < 1 || day > 31 {
+		return 0, 0, false
+	}
+	return time.Month(month), day, true
+}
+
+//NewFiscalCalendar builds the calendar from the cover page dei facts, falling back to the fiscal year end in the
+//SEC header and then to a calendar year when neither has one
+func NewFiscalCalendar(cover FilingCover, header SECHeader) FiscalCalendar {
+	calendar := FiscalCalendar{YearEndMonth: time.December, YearEndDay: 31, documentPeriod: cover.DocumentFiscalPeriodFocus}
+	if month, day, ok := parseFiscalYearEnd(cover.CurrentFiscalYearEndDate); ok {
+		calendar.YearEndMonth, calendar.YearEndDay = month, day
+	} else if month, day, ok := parseFiscalYearEnd(header.FiscalYearEnd); ok {
+		calendar.YearEndMonth, calendar.YearEndDay = month, day
+	}
+
+	calendar.documentPeriodEnd = cover.DocumentPeriodEndDate.Date
+	if !cover.DocumentPeriodEndDate.Valid {
+		calendar.documentPeriodEnd = header.PeriodOfReport
+	}
+	//companies name their fiscal years differently (a year ending Feb. 1, 2020 is fiscal 2019 for some retailers),
+	//so the year the filing says it covers decides the naming for every column
+	if fiscalYearFocus, err := strconv.Atoi(cover.DocumentFiscalYearFocus); err == nil && calendar.documentPeriodEnd.IsValid() {
+		calendar.yearOffset = fiscalYearFocus - calendar.fiscalYearEnding(calendar.documentPeriodEnd)
+		if calendar.yearOffset < -1 || calendar.yearOffset > 1 {
+			calendar.yearOffset = 0
+		}
+	}
+	return calendar
+}
+
+//yearEnd is the nominal end of the fiscal year ending in the given calendar year
+func (f FiscalCalendar) yearEnd(year int) civil.Date {
+	day := f.YearEndDay
+	if last := lastDayOfMonth(year, f.YearEndMonth).Day; day > last {
+		day = last
+	}
+	return civil.Date{Year: year, Month: f.YearEndMonth, Day: day}
+}
+
+//fiscalYearEnding returns the calendar year of the nominal end of the fiscal year a date falls in
+func (f FiscalCalendar) fiscalYearEnding(date civil.Date) int {
+	for year := date.Year - 1; year <= date.Year+1; year++ {
+		if date.DaysSince(f.yearEnd(year)) <= fiscalYearEndTolerance {
+			return year
+		}
+	}
+	return date.Year + 1
+}
+
+//Assign returns the fiscal year and fiscal quarter (Q1-Q4, or FY for a full year duration) of a period
+func (f FiscalCalendar) Assign(start bigquery.NullDate, end bigquery.NullDate, months int) (int, string) {
+	if !end.Valid {
+		return 0, ""
+	}
+	yearEnding := f.fiscalYearEnding(end.Date)
+	fiscalYear := yearEnding + f.yearOffset
+	if months == 0 && start.Valid {
+		months = int(math.Round(float64(end.Date.DaysSince(start.Date)+1) / 30.44))
+	}
+	if months >= 12 {
+		return fiscalYear, "FY"
+	}
+	//the period the filing says it covers only names its instants and its quarter. A 6 or 9 month year to date column
+	//(or a 10-K's fourth quarter column) ending on the same date is labeled from its own length below
+	if end.Date == f.documentPeriodEnd && f.documentPeriod != "" && (months == 0 || months == 3 && f.documentPeriod != "FY") {
+		return fiscalYear, f.documentPeriod
+	}
+	monthsIntoYear := int(math.Round(float64(end.Date.DaysSince(f.yearEnd(yearEnding-1))) / 30.44))
+	quarter := int(math.Ceil(float64(monthsIntoYear) / 3))
+	if quarter < 1 {
+		quarter = 1
+	}
+	if quarter >= 4 {
+		//instants at the end of the fiscal year belong to the annual report like full year durations
+		if months == 0 {
+			return fiscalYear, "FY"
+		}
+		quarter = 4
+	}
+	return fiscalYear, fmt.Sprintf("Q%d", quarter)
+}
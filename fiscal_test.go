@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+func TestFiscalCalendarAssign(t *testing.T) {
+	date := func(year int, month time.Month, day int) bigquery.NullDate {
+		return bigquery.NullDate{Date: civil.Date{Year: year, Month: month, Day: day}, Valid: true}
+	}
+	//Apple's fiscal 2019 10-Q for the quarter ended Jun. 29, 2019 and its 10-K for the year ended Sep. 28, 2019
+	apple := func(period string, end bigquery.NullDate) FiscalCalendar {
+		return NewFiscalCalendar(FilingCover{DocumentPeriodEndDate: end, DocumentFiscalYearFocus: "2019", DocumentFiscalPeriodFocus: period, CurrentFiscalYearEndDate: "--09-28"}, SECHeader{})
+	}
+	quarterly := apple("Q3", date(2019, time.June, 29))
+	annual := apple("FY", date(2019, time.September, 28))
+	tests := []struct {
+		name     string
+		calendar FiscalCalendar
+		start    bigquery.NullDate
+		end      bigquery.NullDate
+		months   int
+		year     int
+		quarter  string
+	}{
+		{"balance sheet instant of the quarter", quarterly, bigquery.NullDate{}, date(2019, time.June, 29), 0, 2019, "Q3"},
+		{"quarter the filing covers", quarterly, date(2019, time.March, 31), date(2019, time.June, 29), 3, 2019, "Q3"},
+		{"nine months to date end in the third quarter", quarterly, date(2018, time.September, 30), date(2019, time.June, 29), 9, 2019, "Q3"},
+		{"prior year comparative", quarterly, date(2018, time.April, 1), date(2018, time.June, 30), 3, 2018, "Q3"},
+		{"prior year end instant", quarterly, bigquery.NullDate{}, date(2018, time.September, 29), 0, 2018, "FY"},
+		{"annual report year", annual, date(2018, time.September, 30), date(2019, time.September, 28), 12, 2019, "FY"},
+		{"annual report instant", annual, bigquery.NullDate{}, date(2019, time.September, 28), 0, 2019, "FY"},
+		{"fourth quarter in an annual report", annual, date(2019, time.June, 30), date(2019, time.September, 28), 3, 2019, "Q4"},
+		{"months worked out from the dates", quarterly, date(2018, time.September, 30), date(2018, time.December, 29), 0, 2019, "Q1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			year, quarter := test.calendar.Assign(test.start, test.end, test.months)
+			if year != test.year || quarter != test.quarter {
+				t.Errorf("Assign(%v, %v, %d) = %d %s, want %d %s", test.start, test.end, test.months, year, quarter, test.year, test.quarter)
+			}
+		})
+	}
+}
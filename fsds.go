@@ -0,0 +1,332 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+//maxNumeric bounds the values a BigQuery NUMERIC column holds, 29 digits before the decimal point
+var maxNumeric, _ = new(big.Rat).SetString("99999999999999999999999999999.999999999")
+
+//FSDSSubmission is a row of sub.txt, one per filing in the Financial Statement Data Sets
+type FSDSSubmission struct {
+	Dataset            string
+	AccessionNumber    string
+	CIK                string
+	Name               string
+	SIC                string
+	CountryBA          string
+	StateBA            string
+	CityBA             string
+	CountryInc         string
+	StateInc           string
+	EIN                string
+	FormerName         string
+	Changed            bigquery.NullDate
+	FilerStatus        string
+	WellKnownSeasoned  bool
+	FiscalYearEnd      string
+	Form               string
+	Period             bigquery.NullDate
+	FiscalYear         int
+	FiscalPeriod       string
+	Filed              bigquery.NullDate
+	AcceptanceDateTime bigquery.NullTimestamp
+	PreviousReport     bool
+	Detail             bool
+	Instance           string
+	NumberOfCIKs       int
+	AdditionalCIKs     string
+}
+
+//FSDSNumber is a row of num.txt, a single numeric fact of a filing
+type FSDSNumber struct {
+	Dataset         string
+	AccessionNumber string
+	Tag             string
+	Version         string
+	Coregistrant    string
+	Segments        string
+	PeriodEnd       bigquery.NullDate
+	Quarters        int
+	Unit            string
+	Value           *big.Rat `bigquery:",nullable"`
+	Footnote        string
+}
+
+//FSDSPresentation is a row of pre.txt, where a fact's tag is shown on a statement
+type FSDSPresentation struct {
+	Dataset         string
+	AccessionNumber string
+	Report          int
+	Line            int
+	Statement       string
+	InParentheses   bool
+	RenderFile      string
+	Tag             string
+	Version         string
+	Label           string
+	Negating        bool
+}
+
+//FSDSTag is a row of tag.txt, the definition of a standard or custom tag
+type FSDSTag struct {
+	Dataset       string
+	Tag           string
+	Version       string
+	Custom        bool
+	Abstract      bool
+	DataType      string
+	PeriodType    string
+	BalanceType   string
+	Label         string
+	Documentation string
+}
+
+//fsdsRow is a line of a data set file, read by column name as the column order changed over the years
+type fsdsRow struct {
+	columns map[string]int
+	fields  []string
+}
+
+func (r fsdsRow) get(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return r.fields[i]
+}
+
+func (r fsdsRow) int(column string) int {
+	value, _ := strconv.Atoi(r.get(column))
+	return value
+}
+
+func (r fsdsRow) bool(column string) bool {
+	return r.get(column) == "1"
+}
+
+//date reads the yyyymmdd dates of the data sets
+func (r fsdsRow) date(column string) bigquery.NullDate {
+	date, err := time.Parse("20060102", r.get(column))
+	if err != nil {
+		return bigquery.NullDate{}
+	}
+	return nullDate(civil.DateOf(date))
+}
+
+//readFSDSFile streams the rows of a tab delimited data set file to fn, the first line names the columns
+func readFSDSFile(file io.Reader, fn func(fsdsRow) error) error {
+	reader := bufio.NewReaderSize(file, 1<<20)
+	var columns map[string]int
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			fields := strings.Split(line, "\t")
+			if columns == nil {
+				columns = make(map[string]int)
+				for i, column := range fields {
+					columns[strings.ToLower(column)] = i
+				}
+			} else if fnErr := fn(fsdsRow{columns: columns, fields: fields}); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func parseFSDSSubmission(dataset string, row fsdsRow) FSDSSubmission {
+	submission := FSDSSubmission{Dataset: dataset, AccessionNumber: row.get("adsh"), CIK: row.get("cik"), Name: row.get("name"), SIC: row.get("sic"), CountryBA: row.get("countryba"), StateBA: row.get("stprba"), CityBA: row.get("cityba"), CountryInc: row.get("countryinc"), StateInc: row.get("stprinc"), EIN: row.get("ein"), FormerName: row.get("former"), Changed: row.date("changed"), FilerStatus: row.get("afs"), WellKnownSeasoned: row.bool("wksi"), FiscalYearEnd: row.get("fye"), Form: row.get("form"), Period: row.date("period"), FiscalYear: row.int("fy"), FiscalPeriod: row.get("fp"), Filed: row.date("filed"), PreviousReport: row.bool("prevrpt"), Detail: row.bool("detail"), Instance: row.get("instance"), NumberOfCIKs: row.int("nciks"), AdditionalCIKs: row.get("aciks")}
+	if accepted, err := time.ParseInLocation("2006-01-02 15:04:05.0", row.get("accepted"), edgarLocation); err == nil {
+		submission.AcceptanceDateTime = bigquery.NullTimestamp{Timestamp: accepted, Valid: true}
+	}
+	return submission
+}
+
+func parseFSDSNumber(dataset string, row fsdsRow) FSDSNumber {
+	number := FSDSNumber{Dataset: dataset, AccessionNumber: row.get("adsh"), Tag: row.get("tag"), Version: row.get("version"), Coregistrant: row.get("coreg"), Segments: row.get("segments"), PeriodEnd: row.date("ddate"), Quarters: row.int("qtrs"), Unit: row.get("uom"), Footnote: row.get("footnote")}
+	number.Value = parseNumeric(row.get("value"))
+	return number
+}
+
+//parseNumeric reads a value for a NUMERIC column, rounded to its 9 decimals. Values it can't hold are left NULL
+func parseNumeric(text string) *big.Rat {
+	value, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil
+	}
+	value, _ = new(big.Rat).SetString(value.FloatString(9))
+	if new(big.Rat).Abs(value).Cmp(maxNumeric) > 0 {
+		return nil
+	}
+	return value
+}
+
+func parseFSDSPresentation(dataset string, row fsdsRow) FSDSPresentation {
+	return FSDSPresentation{Dataset: dataset, AccessionNumber: row.get("adsh"), Report: row.int("report"), Line: row.int("line"), Statement: row.get("stmt"), InParentheses: row.bool("inpth"), RenderFile: row.get("rfile"), Tag: row.get("tag"), Version: row.get("version"), Label: row.get("plabel"), Negating: row.bool("negating")}
+}
+
+func parseFSDSTag(dataset string, row fsdsRow) FSDSTag {
+	tag := FSDSTag{Dataset: dataset, Tag: row.get("tag"), Version: row.get("version"), Custom: row.bool("custom"), Abstract: row.bool("abstract"), DataType: row.get("datatype"), BalanceType: row.get("crdr"), Label: row.get("tlabel"), Documentation: row.get("doc")}
+	switch row.get("iord") {
+	case "I":
+		tag.PeriodType = "instant"
+	case "D":
+		tag.PeriodType = "duration"
+	}
+	return tag
+}
+
+//dataSetFile is a file of a data set zip with the table it is loaded into, its row type and how a row is typed
+type dataSetFile struct {
+	name  string
+	table string
+	row   interface{}
+	parse func(string, fsdsRow) interface{}
+}
+
+var fsdsFiles = []dataSetFile{
+	{"sub.txt", "fsds_sub", FSDSSubmission{}, func(dataset string, row fsdsRow) interface{} { return parseFSDSSubmission(dataset, row) }},
+	{"tag.txt", "fsds_tag", FSDSTag{}, func(dataset string, row fsdsRow) interface{} { return parseFSDSTag(dataset, row) }},
+	{"num.txt", "fsds_num", FSDSNumber{}, func(dataset string, row fsdsRow) interface{} { return parseFSDSNumber(dataset, row) }},
+	{"pre.txt", "fsds_pre", FSDSPresentation{}, func(dataset string, row fsdsRow) interface{} { return parseFSDSPresentation(dataset, row) }},
+}
+
+//LoadFSDS converts the sub, tag, num and pre files of a Financial Statement Data Sets zip (e.g. 2020q1.zip) to
+//newline delimited JSON and hands each to load with its table and the data set's name. Files are streamed out of
+//the zip into a temporary file, so neither the zip nor a whole file is held in memory
+func LoadFSDS(zipPath string, load func(table string, schema bigquery.Schema, dataset string, rows io.Reader) error) error {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	dataset := strings.TrimSuffix(filepath.Base(zipPath), filepath.Ext(zipPath))
+	for _, fsdsFile := range fsdsFiles {
+		for _, file := range archive.File {
+			if file.Name != fsdsFile.name {
+				continue
+			}
+			if err := loadFSDSFile(file, fsdsFile, dataset, load); err != nil {
+				return err
+			}
+			fmt.Println(dataset, fsdsFile.name, "loaded")
+		}
+	}
+	return nil
+}
+
+//loadFSDSFile writes the rows of one data set file to a temporary file and loads it
+func loadFSDSFile(file *zip.File, fsdsFile dataSetFile, dataset string, load func(table string, schema bigquery.Schema, dataset string, rows io.Reader) error) error {
+	schema, err := bigquery.InferSchema(fsdsFile.row)
+	if err != nil {
+		return err
+	}
+	contents, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer contents.Close()
+	temp, err := ioutil.TempFile("", dataset+"-"+fsdsFile.table+"-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+
+	writer := bufio.NewWriterSize(temp, 1<<20)
+	encoder := json.NewEncoder(writer)
+	err = readFSDSFile(contents, func(row fsdsRow) error {
+		return encodeRow(encoder, schema, fsdsFile.parse(dataset, row))
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if _, err := temp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return load(fsdsFile.table, schema, dataset, temp)
+}
+
+//FSDSDifferencesViewQuery lines up the values we parsed from R pages with the same facts in the data sets, matched
+//on filing, tag, period end and length for facts without dimensions. R pages show some values negated, so a value
+//matching in absolute terms is flagged separately
+func FSDSDifferencesViewQuery(projectName string) string {
+	return fmt.Sprintf("SELECT r.CIK, r.AccessionNumber, r.Statement, r.Concept, r.Item, r.PeriodStart, r.PeriodEnd, r.PeriodMonths, r.Unit, r.NumericValue, n.Value AS FSDSValue, n.Unit AS FSDSUnit,\n"+
+		"r.NumericValue = n.Value AS Matches, ABS(r.NumericValue) = ABS(n.Value) AS MatchesIgnoringSign\n"+
+		"FROM `%[1]s.SEC.reported_values` r\n"+
+		"JOIN `%[1]s.SEC.fsds_num` n ON n.AccessionNumber = r.AccessionNumber AND n.Tag = SPLIT(r.Concept, ':')[SAFE_OFFSET(1)] AND n.PeriodEnd = r.PeriodEnd AND n.Quarters * 3 = r.PeriodMonths\n"+
+		"WHERE r.DimensionKey = '' AND n.Coregistrant = '' AND n.Segments = ''", projectName)
+}
+
+//fsdsCommand loads Financial Statement Data Sets zips downloaded from the SEC into the fsds tables
+func fsdsCommand(args []string) {
+	flags := flag.NewFlagSet("fsds", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal("usage: fsds <data set zip>...")
+	}
+
+	fmt.Println("Please enter in your Google Cloud project name: ")
+	var projectName string
+	fmt.Scanln(&projectName)
+	ctx := context.Background()
+	bq, err := bigquery.NewClient(ctx, projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bq.Close()
+	ds := bq.Dataset("SEC")
+	for _, fsdsFile := range fsdsFiles {
+		schema, _ := bigquery.InferSchema(fsdsFile.row)
+		if err := createTable(ctx, ds.Table(fsdsFile.table), schema); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if err := ds.Table("fsds_differences").Create(ctx, &bigquery.TableMetadata{ViewQuery: FSDSDifferencesViewQuery(projectName)}); err != nil {
+		fmt.Println(err)
+	}
+
+	//Drop what an earlier run loaded from the same zip so reruns don't duplicate rows. Rows are written with load jobs
+	//as streamed rows can't be deleted for a while
+	load := func(table string, schema bigquery.Schema, dataset string, rows io.Reader) error {
+		q := bq.Query(fmt.Sprintf("DELETE FROM `%s.SEC.%s` WHERE Dataset = @dataset", projectName, table))
+		q.Parameters = []bigquery.QueryParameter{{Name: "dataset", Value: dataset}}
+		if err := runStatement(ctx, q); err != nil {
+			return err
+		}
+		return loadReader(ctx, ds.Table(table), schema, rows, bigquery.WriteAppend)
+	}
+	for _, zipPath := range flags.Args() {
+		if err := LoadFSDS(zipPath, load); err != nil {
+			fmt.Println("Can't load financial statement data set", zipPath)
+			log.Fatal(err)
+		}
+	}
+}
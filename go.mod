@@ -3,8 +3,11 @@ module github.com/Sampada-DeFi/Sampada-Research-ETL
 go 1.16
 
 require (
+	cloud.google.com/go v0.84.0
 	cloud.google.com/go/bigquery v1.19.0
-	cloud.google.com/go/storage v1.16.0 // indirect
+	cloud.google.com/go/storage v1.16.0
 	github.com/anaskhan96/soup v1.2.4
+	golang.org/x/net v0.0.0-20210503060351-7fd8e65b6420
 	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
+	google.golang.org/api v0.49.0
 )
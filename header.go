@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+//SECHeader holds the fields of a filing's SEC header (the .hdr.sgml file in the filing directory) used by the ETL
+type SECHeader struct {
+	AccessionNumber      string
+	SubmissionType       string
+	PeriodOfReport       civil.Date
+	FiledAsOfDate        civil.Date
+	AcceptanceDateTime   time.Time
+	CompanyName          string
+	CIK                  string
+	StandardIndustrial   string
+	StateOfIncorporation string
+	FiscalYearEnd        string
+	Items                []string
+}
+
+//edgarLocation is the time zone EDGAR acceptance datetimes are reported in
+var edgarLocation = func() *time.Location {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return location
+}()
+
+func parseHeaderDate(value string) civil.Date {
+	date, err := time.Parse("20060102", value)
+	if err != nil {
+		return civil.Date{}
+	}
+	return civil.DateOf(date)
+}
+
+//ParseSECHeader reads the "KEY: value" lines of an SEC header. Only the first filer's company data is kept,
+//which is the registrant for the periodic reports the ETL loads
+func ParseSECHeader(header []byte) SECHeader {
+	var secHeader SECHeader
+	scanner := bufio.NewScanner(strings.NewReader(string(header)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "<ACCEPTANCE-DATETIME>") {
+			acceptance, err := time.ParseInLocation("20060102150405", strings.TrimPrefix(line, "<ACCEPTANCE-DATETIME>"), edgarLocation)
+			if err == nil {
+				secHeader.AcceptanceDateTime = acceptance
+			}
+			continue
+		}
+		separator := strings.Index(line, ":")
+		if separator < 0 {
+			continue
+		}
+		key, value := strings.TrimSpace(line[:separator]), strings.TrimSpace(line[separator+1:])
+		switch key {
+		case "ACCESSION NUMBER":
+			secHeader.AccessionNumber = value
+		case "CONFORMED SUBMISSION TYPE":
+			secHeader.SubmissionType = value
+		case "CONFORMED PERIOD OF REPORT":
+			secHeader.PeriodOfReport = parseHeaderDate(value)
+		case "FILED AS OF DATE":
+			secHeader.FiledAsOfDate = parseHeaderDate(value)
+		case "ITEM INFORMATION":
+			secHeader.Items = append(secHeader.Items, value)
+		case "COMPANY CONFORMED NAME":
+			if secHeader.CompanyName == "" {
+				secHeader.CompanyName = value
+			}
+		case "CENTRAL INDEX KEY":
+			if secHeader.CIK == "" {
+				secHeader.CIK = value
+			}
+		case "STANDARD INDUSTRIAL CLASSIFICATION":
+			if secHeader.StandardIndustrial == "" {
+				secHeader.StandardIndustrial = value
+			}
+		case "STATE OF INCORPORATION":
+			if secHeader.StateOfIncorporation == "" {
+				secHeader.StateOfIncorporation = value
+			}
+		case "FISCAL YEAR END":
+			if secHeader.FiscalYearEnd == "" {
+				secHeader.FiscalYearEnd = value
+			}
+		}
+	}
+	return secHeader
+}
+
+//SECHeaderURL is the location of the header of a filing within its directory
+func SECHeaderURL(filingDirectoryIndexURL string, accessionNumber string) string {
+	return filingDirectoryIndexURL + "/" + accessionNumber + ".hdr.sgml"
+}
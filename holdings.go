@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+//valueInDollarsFrom is when 13F information tables switched from reporting values in thousands to whole dollars
+var valueInDollarsFrom = civil.Date{Year: 2023, Month: time.January, Day: 3}
+
+//isInstitutionalHoldingsForm reports whether a form is a 13F holdings report or an amendment to one
+func isInstitutionalHoldingsForm(form string) bool {
+	return form == "13F-HR" || form == "13F-HR/A"
+}
+
+//ThirteenFCover is the primary document of a 13F-HR, the manager, report period and summary of the information table
+type ThirteenFCover struct {
+	PeriodOfReport string `xml:"headerData>filerInfo>periodOfReport"`
+	FilerCIK       string `xml:"headerData>filerInfo>filer>credentials>cik"`
+	CoverPage      struct {
+		ReportCalendarOrQuarter string `xml:"reportCalendarOrQuarter"`
+		IsAmendment             string `xml:"isAmendment"`
+		AmendmentNumber         string `xml:"amendmentNo"`
+		AmendmentType           string `xml:"amendmentInfo>amendmentType"`
+		ManagerName             string `xml:"filingManager>name"`
+		City                    string `xml:"filingManager>address>city"`
+		StateOrCountry          string `xml:"filingManager>address>stateOrCountry"`
+		ReportType              string `xml:"reportType"`
+		FileNumber              string `xml:"form13FFileNumber"`
+	} `xml:"formData>coverPage"`
+	SummaryPage struct {
+		OtherIncludedManagersCount string `xml:"otherIncludedManagersCount"`
+		TableEntryTotal            string `xml:"tableEntryTotal"`
+		TableValueTotal            string `xml:"tableValueTotal"`
+		IsConfidentialOmitted      string `xml:"isConfidentialOmitted"`
+	} `xml:"formData>summaryPage"`
+}
+
+//ThirteenFInformationTable is the information table of a 13F-HR, a row per position
+type ThirteenFInformationTable struct {
+	Entries []struct {
+		NameOfIssuer          string `xml:"nameOfIssuer"`
+		TitleOfClass          string `xml:"titleOfClass"`
+		CUSIP                 string `xml:"cusip"`
+		FIGI                  string `xml:"figi"`
+		Value                 string `xml:"value"`
+		SharesOrPrincipal     string `xml:"shrsOrPrnAmt>sshPrnamt"`
+		SharesOrPrincipalType string `xml:"shrsOrPrnAmt>sshPrnamtType"`
+		PutCall               string `xml:"putCall"`
+		InvestmentDiscretion  string `xml:"investmentDiscretion"`
+		OtherManager          string `xml:"otherManager"`
+		VotingSole            string `xml:"votingAuthority>Sole"`
+		VotingShared          string `xml:"votingAuthority>Shared"`
+		VotingNone            string `xml:"votingAuthority>None"`
+	} `xml:"infoTable"`
+}
+
+//InstitutionalFiling is the cover page of a 13F-HR, one per filing. TableValueTotal is in dollars whatever the
+//filing reported it in
+type InstitutionalFiling struct {
+	Year                     string
+	Quarter                  string
+	FilerCIK                 string
+	FilerName                string
+	ReportPeriod             bigquery.NullDate
+	AccessionNumber          string
+	Form                     string
+	FilingDate               bigquery.NullDate
+	IsAmendment              bool
+	AmendmentType            string
+	ReportType               string
+	FileNumber               string
+	City                     string
+	StateOrCountry           string
+	OtherManagersCount       int
+	TableEntryTotal          int
+	TableValueTotal          *big.Rat `bigquery:",nullable"`
+	ValueReportedInThousands bool
+	IsConfidentialOmitted    bool
+}
+
+//InstitutionalHolding is a position in a 13F-HR information table. Value is in dollars, ValueReported is as filed,
+//in thousands before 2023
+type InstitutionalHolding struct {
+	Year                  string
+	Quarter               string
+	FilerCIK              string
+	FilerName             string
+	ReportPeriod          bigquery.NullDate
+	AccessionNumber       string
+	Form                  string
+	FilingDate            bigquery.NullDate
+	IsAmendment           bool
+	AmendmentType         string
+	NameOfIssuer          string
+	TitleOfClass          string
+	CUSIP                 string
+	FIGI                  string
+	Value                 *big.Rat `bigquery:",nullable"`
+	ValueReported         *big.Rat `bigquery:",nullable"`
+	SharesOrPrincipal     *big.Rat `bigquery:",nullable"`
+	SharesOrPrincipalType string
+	PutCall               string
+	InvestmentDiscretion  string
+	OtherManager          string
+	VotingSole            *big.Rat `bigquery:",nullable"`
+	VotingShared          *big.Rat `bigquery:",nullable"`
+	VotingNone            *big.Rat `bigquery:",nullable"`
+}
+
+//Parse13F finds the cover page and information table xml in a 13F-HR's complete submission text file. A 13F notice
+//has no information table
+func Parse13F(submission []byte) (ThirteenFCover, ThirteenFInformationTable, error) {
+	var cover ThirteenFCover
+	var table ThirteenFInformationTable
+	foundCover := false
+	for _, document := range ParseSubmissionDocuments(submission) {
+		text := document.XML()
+		switch {
+		case strings.Contains(text, "edgarSubmission") && !foundCover:
+			if err := decodeSubmissionXML(text, &cover); err != nil {
+				return cover, table, err
+			}
+			foundCover = true
+		case strings.Contains(text, "informationTable"):
+			if err := decodeSubmissionXML(text, &table); err != nil {
+				return cover, table, err
+			}
+		}
+	}
+	if !foundCover {
+		return cover, table, errors.New("no 13F cover page in filing")
+	}
+	return cover, table, nil
+}
+
+//thirteenFNumber reads an amount of the cover page or information table, nil when not reported
+func thirteenFNumber(value string) *big.Rat {
+	number, ok := new(big.Rat).SetString(strings.ReplaceAll(strings.TrimSpace(value), ",", ""))
+	if !ok {
+		return nil
+	}
+	return number
+}
+
+//thirteenFDate reads the MM-DD-YYYY dates of 13F cover pages
+func thirteenFDate(value string) bigquery.NullDate {
+	date, err := time.Parse("01-02-2006", strings.TrimSpace(value))
+	if err != nil {
+		return bigquery.NullDate{}
+	}
+	return nullDate(civil.DateOf(date))
+}
+
+//InstitutionalRows turns a 13F-HR into its cover page row and holdings. Filings made before January 3, 2023 reported
+//values in thousands of dollars, those values are multiplied out so Value is always in dollars
+func InstitutionalRows(cover ThirteenFCover, table ThirteenFInformationTable, year string, qtr string, cik string, accessionNumber string, form string, dateFiled string) (InstitutionalFiling, []InstitutionalHolding) {
+	filingDate := xmlDate(dateFiled)
+	reportPeriod := thirteenFDate(cover.CoverPage.ReportCalendarOrQuarter)
+	if !reportPeriod.Valid {
+		reportPeriod = thirteenFDate(cover.PeriodOfReport)
+	}
+	filerCIK := normalizeCIK(cik)
+	if cover.FilerCIK != "" {
+		filerCIK = normalizeCIK(cover.FilerCIK)
+	}
+	inThousands := filingDate.Valid && filingDate.Date.Before(valueInDollarsFrom)
+	inDollars := func(value *big.Rat) *big.Rat {
+		if value == nil || !inThousands {
+			return value
+		}
+		return new(big.Rat).Mul(value, big.NewRat(1000, 1))
+	}
+	isAmendment := ownershipBool(cover.CoverPage.IsAmendment) || strings.HasSuffix(form, "/A")
+
+	filingRow := InstitutionalFiling{Year: year, Quarter: qtr, FilerCIK: filerCIK, FilerName: cover.CoverPage.ManagerName, ReportPeriod: reportPeriod, AccessionNumber: accessionNumber, Form: form, FilingDate: filingDate, IsAmendment: isAmendment, AmendmentType: cover.CoverPage.AmendmentType, ReportType: cover.CoverPage.ReportType, FileNumber: cover.CoverPage.FileNumber, City: cover.CoverPage.City, StateOrCountry: cover.CoverPage.StateOrCountry, TableValueTotal: inDollars(thirteenFNumber(cover.SummaryPage.TableValueTotal)), ValueReportedInThousands: inThousands, IsConfidentialOmitted: ownershipBool(cover.SummaryPage.IsConfidentialOmitted)}
+	if count := thirteenFNumber(cover.SummaryPage.OtherIncludedManagersCount); count != nil && count.IsInt() {
+		filingRow.OtherManagersCount = int(count.Num().Int64())
+	}
+	if count := thirteenFNumber(cover.SummaryPage.TableEntryTotal); count != nil && count.IsInt() {
+		filingRow.TableEntryTotal = int(count.Num().Int64())
+	}
+
+	var holdingRows []InstitutionalHolding
+	for _, entry := range table.Entries {
+		valueReported := thirteenFNumber(entry.Value)
+		holdingRows = append(holdingRows, InstitutionalHolding{Year: year, Quarter: qtr, FilerCIK: filerCIK, FilerName: cover.CoverPage.ManagerName, ReportPeriod: reportPeriod, AccessionNumber: accessionNumber, Form: form, FilingDate: filingDate, IsAmendment: isAmendment, AmendmentType: cover.CoverPage.AmendmentType,
+			NameOfIssuer: entry.NameOfIssuer, TitleOfClass: entry.TitleOfClass, CUSIP: strings.ToUpper(strings.TrimSpace(entry.CUSIP)), FIGI: entry.FIGI, Value: inDollars(valueReported), ValueReported: valueReported,
+			SharesOrPrincipal: thirteenFNumber(entry.SharesOrPrincipal), SharesOrPrincipalType: entry.SharesOrPrincipalType, PutCall: entry.PutCall, InvestmentDiscretion: entry.InvestmentDiscretion, OtherManager: entry.OtherManager,
+			VotingSole: thirteenFNumber(entry.VotingSole), VotingShared: thirteenFNumber(entry.VotingShared), VotingNone: thirteenFNumber(entry.VotingNone)})
+	}
+	return filingRow, holdingRows
+}
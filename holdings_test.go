@@ -0,0 +1,177 @@
+package main
+
+import (
+	"testing"
+
+	"cloud.google.com/go/civil"
+)
+
+//berkshire13FSubmission is a 13F-HR complete submission text file with its cover page and an information table of
+//a common stock position and a put
+const berkshire13FSubmission = `
+<SEC-DOCUMENT>0001067983-20-000012.txt : 20200214
+<SEC-HEADER>
+CONFORMED SUBMISSION TYPE:	13F-HR
+</SEC-HEADER>
+<DOCUMENT>
+<TYPE>13F-HR
+<SEQUENCE>1
+<FILENAME>primary_doc.xml
+<TEXT>
+<XML>
+<?xml version="1.0" encoding="UTF-8"?>
+<edgarSubmission xmlns="http://www.sec.gov/edgar/thirteenffiler" xmlns:com="http://www.sec.gov/edgar/common">
+  <headerData>
+    <submissionType>13F-HR</submissionType>
+    <filerInfo>
+      <filer><credentials><cik>0001067983</cik><ccc>XXXXXXXX</ccc></credentials></filer>
+      <periodOfReport>12-31-2019</periodOfReport>
+    </filerInfo>
+  </headerData>
+  <formData>
+    <coverPage>
+      <reportCalendarOrQuarter>12-31-2019</reportCalendarOrQuarter>
+      <isAmendment>false</isAmendment>
+      <filingManager><name>Berkshire Hathaway Inc</name><address><com:street1>3555 Farnam Street</com:street1><com:city>Omaha</com:city><com:stateOrCountry>NE</com:stateOrCountry><com:zipCode>68131</com:zipCode></address></filingManager>
+      <reportType>13F HOLDINGS REPORT</reportType>
+      <form13FFileNumber>028-04545</form13FFileNumber>
+    </coverPage>
+    <summaryPage>
+      <otherIncludedManagersCount>14</otherIncludedManagersCount>
+      <tableEntryTotal>2</tableEntryTotal>
+      <tableValueTotal>241,000</tableValueTotal>
+      <isConfidentialOmitted>true</isConfidentialOmitted>
+    </summaryPage>
+  </formData>
+</edgarSubmission>
+</XML>
+</TEXT>
+</DOCUMENT>
+<DOCUMENT>
+<TYPE>INFORMATION TABLE
+<SEQUENCE>2
+<FILENAME>form13fInfoTable.xml
+<TEXT>
+<XML>
+<?xml version="1.0" encoding="UTF-8"?>
+<ns1:informationTable xmlns:ns1="http://www.sec.gov/edgar/document/thirteenf/informationtable">
+  <ns1:infoTable>
+    <ns1:nameOfIssuer>APPLE INC</ns1:nameOfIssuer>
+    <ns1:titleOfClass>COM</ns1:titleOfClass>
+    <ns1:cusip>037833100</ns1:cusip>
+    <ns1:value>240000</ns1:value>
+    <ns1:shrsOrPrnAmt><ns1:sshPrnamt>817300</ns1:sshPrnamt><ns1:sshPrnamtType>SH</ns1:sshPrnamtType></ns1:shrsOrPrnAmt>
+    <ns1:investmentDiscretion>DFND</ns1:investmentDiscretion>
+    <ns1:otherManager>4,8,11</ns1:otherManager>
+    <ns1:votingAuthority><ns1:Sole>817300</ns1:Sole><ns1:Shared>0</ns1:Shared><ns1:None>0</ns1:None></ns1:votingAuthority>
+  </ns1:infoTable>
+  <ns1:infoTable>
+    <ns1:nameOfIssuer>SPDR S&amp;P 500</ns1:nameOfIssuer>
+    <ns1:titleOfClass>TR UNIT</ns1:titleOfClass>
+    <ns1:cusip>78462f103</ns1:cusip>
+    <ns1:value>1000</ns1:value>
+    <ns1:shrsOrPrnAmt><ns1:sshPrnamt>3000</ns1:sshPrnamt><ns1:sshPrnamtType>SH</ns1:sshPrnamtType></ns1:shrsOrPrnAmt>
+    <ns1:putCall>Put</ns1:putCall>
+    <ns1:investmentDiscretion>SOLE</ns1:investmentDiscretion>
+    <ns1:votingAuthority><ns1:Sole>0</ns1:Sole><ns1:Shared>0</ns1:Shared><ns1:None>3000</ns1:None></ns1:votingAuthority>
+  </ns1:infoTable>
+</ns1:informationTable>
+</XML>
+</TEXT>
+</DOCUMENT>
+</SEC-DOCUMENT>
+`
+
+//notice13FSubmission is a 13F notice, a cover page whose holdings are reported by another manager with no
+//information table
+const notice13FSubmission = `
+<SEC-DOCUMENT>0000950123-23-001234.txt : 20230214
+<DOCUMENT>
+<TYPE>13F-NT
+<SEQUENCE>1
+<FILENAME>primary_doc.xml
+<TEXT>
+<XML>
+<?xml version="1.0" encoding="UTF-8"?>
+<edgarSubmission xmlns="http://www.sec.gov/edgar/thirteenffiler">
+  <headerData>
+    <submissionType>13F-NT</submissionType>
+    <filerInfo><filer><credentials><cik>0001423053</cik></credentials></filer><periodOfReport>12-31-2022</periodOfReport></filerInfo>
+  </headerData>
+  <formData>
+    <coverPage>
+      <isAmendment>false</isAmendment>
+      <filingManager><name>Example Advisers LLC</name></filingManager>
+      <reportType>13F NOTICE</reportType>
+    </coverPage>
+  </formData>
+</edgarSubmission>
+</XML>
+</TEXT>
+</DOCUMENT>
+</SEC-DOCUMENT>
+`
+
+func TestInstitutionalRows(t *testing.T) {
+	cover, table, err := Parse13F([]byte(berkshire13FSubmission))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		dateFiled   string
+		inThousands bool
+		tableTotal  string
+		appleValue  string
+		putValue    string
+	}{
+		{"filed before the cutover in thousands", "2020-02-14", true, "241000000", "240000000", "1000000"},
+		{"filed the day before the cutover", "2023-01-02", true, "241000000", "240000000", "1000000"},
+		{"filed on the cutover in dollars", "2023-01-03", false, "241000", "240000", "1000"},
+		{"filed after the cutover in dollars", "2023-02-14", false, "241000", "240000", "1000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filingRow, holdingRows := InstitutionalRows(cover, table, "2020", "QTR1", "1067983", "0001067983-20-000012", "13F-HR", test.dateFiled)
+			if filingRow.ValueReportedInThousands != test.inThousands || ratString(filingRow.TableValueTotal) != test.tableTotal {
+				t.Errorf("ValueReportedInThousands = %v, TableValueTotal = %s, want %v %s", filingRow.ValueReportedInThousands, ratString(filingRow.TableValueTotal), test.inThousands, test.tableTotal)
+			}
+			if len(holdingRows) != 2 {
+				t.Fatalf("got %d holdings, want 2", len(holdingRows))
+			}
+			apple, put := holdingRows[0], holdingRows[1]
+			if ratString(apple.Value) != test.appleValue || ratString(apple.ValueReported) != "240000" {
+				t.Errorf("Value = %s, ValueReported = %s, want %s 240000", ratString(apple.Value), ratString(apple.ValueReported), test.appleValue)
+			}
+			if ratString(put.Value) != test.putValue || put.PutCall != "Put" || put.CUSIP != "78462F103" || put.NameOfIssuer != "SPDR S&P 500" {
+				t.Errorf("put = %+v", put)
+			}
+		})
+	}
+
+	filingRow, holdingRows := InstitutionalRows(cover, table, "2020", "QTR1", "1067983", "0001067983-20-000012", "13F-HR", "2020-02-14")
+	if filingRow.FilerCIK != "1067983" || filingRow.FilerName != "Berkshire Hathaway Inc" || filingRow.ReportPeriod != nullDate(civil.Date{Year: 2019, Month: 12, Day: 31}) || filingRow.IsAmendment || filingRow.OtherManagersCount != 14 || filingRow.TableEntryTotal != 2 || !filingRow.IsConfidentialOmitted {
+		t.Errorf("filing = %+v", filingRow)
+	}
+	apple := holdingRows[0]
+	if ratString(apple.SharesOrPrincipal) != "817300" || apple.InvestmentDiscretion != "DFND" || apple.OtherManager != "4,8,11" || ratString(apple.VotingSole) != "817300" || ratString(apple.VotingNone) != "0" {
+		t.Errorf("holding = %+v", apple)
+	}
+}
+
+func TestInstitutionalRowsNotice(t *testing.T) {
+	cover, table, err := Parse13F([]byte(notice13FSubmission))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filingRow, holdingRows := InstitutionalRows(cover, table, "2023", "QTR1", "1423053", "0000950123-23-001234", "13F-NT", "2023-02-14")
+	if len(holdingRows) != 0 {
+		t.Errorf("got %d holdings, want none", len(holdingRows))
+	}
+	if filingRow.ReportType != "13F NOTICE" || filingRow.FilerName != "Example Advisers LLC" || filingRow.TableValueTotal != nil || filingRow.ReportPeriod != nullDate(civil.Date{Year: 2022, Month: 12, Day: 31}) {
+		t.Errorf("filing = %+v", filingRow)
+	}
+	if _, _, err := Parse13F([]byte(appleForm4Submission)); err == nil {
+		t.Error("Parse13F of a filing without a cover page didn't fail")
+	}
+}
@@ -0,0 +1,550 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//loaderFlags are the options of every command that loads filings
+type loaderFlags struct {
+	rulesPath    *string
+	mappingsPath *string
+	quarantine   *bool
+}
+
+func addLoaderFlags(flags *flag.FlagSet) loaderFlags {
+	return loaderFlags{
+		rulesPath:    flags.String("rules", "", "path to a statement rules file, defaults to the bundled statement_rules.json"),
+		mappingsPath: flags.String("mappings", "", "path to a standardized account mapping file, defaults to the bundled standard_accounts.json"),
+		quarantine:   flags.Bool("quarantine", false, "hold back filings that fail the accounting identity checks from the statement tables"),
+	}
+}
+
+//rules loads the statement and mapping rules files passed, or the bundled ones
+func (f loaderFlags) rules() (StatementRules, MappingRules) {
+	statementRules := DefaultStatementRules()
+	if *f.rulesPath != "" {
+		rules, err := LoadStatementRules(*f.rulesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		statementRules = rules
+	}
+	mappingRules := DefaultMappingRules()
+	if *f.mappingsPath != "" {
+		rules, err := LoadMappingRules(*f.mappingsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mappingRules = rules
+	}
+	return statementRules, mappingRules
+}
+
+//FilingLoader parses filings and uploads them to the statement tables
+type FilingLoader struct {
+	ctx                         context.Context
+	bq                          *bigquery.Client
+	projectName                 string
+	client                      *RLHTTPClient
+	userAgent                   string
+	statementRules              StatementRules
+	mappingRules                MappingRules
+	quarantine                  bool
+	balanceSheetTable           *stagedTable
+	incomeStatementTable        *stagedTable
+	cashFlowStatementTable      *stagedTable
+	stockholdersEquityTable     *stagedTable
+	comprehensiveIncomeTable    *stagedTable
+	parentheticalTable          *stagedTable
+	filingStatementsTable       *stagedTable
+	notesTable                  *stagedTable
+	factsTable                  *stagedTable
+	filingCoverTable            *stagedTable
+	standardizedFinancialsTable *stagedTable
+	reportedValuesTable         *stagedTable
+	validationTable             *stagedTable
+	quarantinedFilingsTable     *stagedTable
+	insiderOwnersTable          *stagedTable
+	insiderTransactionsTable    *stagedTable
+	insiderHoldingsTable        *stagedTable
+	institutionalFilingsTable   *stagedTable
+	institutionalHoldingsTable  *stagedTable
+	eventsTable                 *stagedTable
+	narrativeSectionsTable      *stagedTable
+	loadedFilingsTable          *stagedTable
+	//tables lists the staged tables in the order they are written, loaded_filings last
+	tables []*stagedTable
+	//accessionNumbers lists the filings staged since the last flush
+	accessionNumbers []string
+}
+
+//NewFilingLoader creates the tables and views filings are loaded into, or adds the columns of newer rows to the tables
+//that exist, printing the error when one already exists
+func NewFilingLoader(ctx context.Context, bq *bigquery.Client, ds *bigquery.Dataset, projectName string, client *RLHTTPClient, userAgent string, statementRules StatementRules, mappingRules MappingRules, quarantine bool) *FilingLoader {
+	balanceSheetTable := ds.Table("balance-sheet")
+	balanceSheetSchema, _ := bigquery.InferSchema(BalanceSheetItem{})
+	if err := createTable(ctx, balanceSheetTable, balanceSheetSchema); err != nil {
+		fmt.Println(err)
+	}
+	incomeStatementTable := ds.Table("income-statement")
+	incomeStatementSchema, _ := bigquery.InferSchema(IncomeOrCashFlowStatementItem{})
+	if err := createTable(ctx, incomeStatementTable, incomeStatementSchema); err != nil {
+		fmt.Println(err)
+	}
+	cashFlowStatementTable := ds.Table("cash-flow-statement")
+	cashFlowStatementSchema, _ := bigquery.InferSchema(IncomeOrCashFlowStatementItem{})
+	if err := createTable(ctx, cashFlowStatementTable, cashFlowStatementSchema); err != nil {
+		fmt.Println(err)
+	}
+	stockholdersEquityTable := ds.Table("stockholders-equity")
+	stockholdersEquitySchema, _ := bigquery.InferSchema(StockholdersEquityItem{})
+	if err := createTable(ctx, stockholdersEquityTable, stockholdersEquitySchema); err != nil {
+		fmt.Println(err)
+	}
+	comprehensiveIncomeTable := ds.Table("comprehensive-income")
+	comprehensiveIncomeSchema, _ := bigquery.InferSchema(IncomeOrCashFlowStatementItem{})
+	if err := createTable(ctx, comprehensiveIncomeTable, comprehensiveIncomeSchema); err != nil {
+		fmt.Println(err)
+	}
+	parentheticalTable := ds.Table("parenthetical")
+	parentheticalSchema, _ := bigquery.InferSchema(ParentheticalItem{})
+	if err := createTable(ctx, parentheticalTable, parentheticalSchema); err != nil {
+		fmt.Println(err)
+	}
+	filingStatementsTable := ds.Table("filing-statements")
+	filingStatementsSchema, _ := bigquery.InferSchema(FilingStatementItem{})
+	if err := createTable(ctx, filingStatementsTable, filingStatementsSchema); err != nil {
+		fmt.Println(err)
+	}
+	notesTable := ds.Table("notes")
+	notesSchema, _ := bigquery.InferSchema(NoteItem{})
+	if err := createTable(ctx, notesTable, notesSchema); err != nil {
+		fmt.Println(err)
+	}
+	factsTable := ds.Table("facts")
+	factsSchema, _ := bigquery.InferSchema(FactItem{})
+	if err := createTable(ctx, factsTable, factsSchema); err != nil {
+		fmt.Println(err)
+	}
+	filingCoverTable := ds.Table("filing_cover")
+	filingCoverSchema, _ := bigquery.InferSchema(FilingCover{})
+	if err := createTable(ctx, filingCoverTable, filingCoverSchema); err != nil {
+		fmt.Println(err)
+	}
+	standardizedFinancialsTable := ds.Table("standardized_financials")
+	standardizedFinancialsSchema, _ := bigquery.InferSchema(StandardizedItem{})
+	if err := createTable(ctx, standardizedFinancialsTable, standardizedFinancialsSchema); err != nil {
+		fmt.Println(err)
+	}
+	reportedValuesTable := ds.Table("reported_values")
+	reportedValuesSchema, _ := bigquery.InferSchema(ReportedValue{})
+	if err := createTable(ctx, reportedValuesTable, reportedValuesSchema); err != nil {
+		fmt.Println(err)
+	}
+	if err := ds.Table("reported_values_first").Create(ctx, &bigquery.TableMetadata{ViewQuery: FirstReportedViewQuery(projectName)}); err != nil {
+		fmt.Println(err)
+	}
+	if err := ds.Table("reported_values_latest").Create(ctx, &bigquery.TableMetadata{ViewQuery: LatestReportedViewQuery(projectName)}); err != nil {
+		fmt.Println(err)
+	}
+	validationTable := ds.Table("validation_results")
+	validationSchema, _ := bigquery.InferSchema(ValidationItem{})
+	if err := createTable(ctx, validationTable, validationSchema); err != nil {
+		fmt.Println(err)
+	}
+	quarantinedFilingsTable := ds.Table("quarantined_filings")
+	quarantinedFilingsSchema, _ := bigquery.InferSchema(QuarantinedFiling{})
+	if err := createTable(ctx, quarantinedFilingsTable, quarantinedFilingsSchema); err != nil {
+		fmt.Println(err)
+	}
+	insiderOwnersTable := ds.Table("insider_reporting_owners")
+	insiderOwnersSchema, _ := bigquery.InferSchema(InsiderReportingOwner{})
+	if err := createTable(ctx, insiderOwnersTable, insiderOwnersSchema); err != nil {
+		fmt.Println(err)
+	}
+	insiderTransactionsTable := ds.Table("insider_transactions")
+	insiderTransactionsSchema, _ := bigquery.InferSchema(InsiderTransaction{})
+	if err := createTable(ctx, insiderTransactionsTable, insiderTransactionsSchema); err != nil {
+		fmt.Println(err)
+	}
+	insiderHoldingsTable := ds.Table("insider_holdings")
+	insiderHoldingsSchema, _ := bigquery.InferSchema(InsiderHolding{})
+	if err := createTable(ctx, insiderHoldingsTable, insiderHoldingsSchema); err != nil {
+		fmt.Println(err)
+	}
+	institutionalFilingsTable := ds.Table("institutional_filings")
+	institutionalFilingsSchema, _ := bigquery.InferSchema(InstitutionalFiling{})
+	if err := createTable(ctx, institutionalFilingsTable, institutionalFilingsSchema); err != nil {
+		fmt.Println(err)
+	}
+	institutionalHoldingsTable := ds.Table("institutional_holdings")
+	institutionalHoldingsSchema, _ := bigquery.InferSchema(InstitutionalHolding{})
+	if err := createTable(ctx, institutionalHoldingsTable, institutionalHoldingsSchema); err != nil {
+		fmt.Println(err)
+	}
+	eventsTable := ds.Table("events")
+	eventsSchema, _ := bigquery.InferSchema(EventFiling{})
+	if err := createTable(ctx, eventsTable, eventsSchema); err != nil {
+		fmt.Println(err)
+	}
+	narrativeSectionsTable := ds.Table("narrative_sections")
+	narrativeSectionsSchema, _ := bigquery.InferSchema(NarrativeSection{})
+	if err := createTable(ctx, narrativeSectionsTable, narrativeSectionsSchema); err != nil {
+		fmt.Println(err)
+	}
+	if err := ds.Table("narrative_changes").Create(ctx, &bigquery.TableMetadata{ViewQuery: NarrativeChangesViewQuery(projectName)}); err != nil {
+		fmt.Println(err)
+	}
+	loadedFilingsTable := ds.Table("loaded_filings")
+	loadedFilingsSchema, _ := bigquery.InferSchema(LoadedFiling{})
+	if err := createTable(ctx, loadedFilingsTable, loadedFilingsSchema); err != nil {
+		fmt.Println(err)
+	}
+	loader := &FilingLoader{ctx: ctx, bq: bq, projectName: projectName, client: client, userAgent: userAgent, statementRules: statementRules, mappingRules: mappingRules, quarantine: quarantine,
+		balanceSheetTable:           &stagedTable{table: balanceSheetTable, schema: balanceSheetSchema},
+		incomeStatementTable:        &stagedTable{table: incomeStatementTable, schema: incomeStatementSchema},
+		cashFlowStatementTable:      &stagedTable{table: cashFlowStatementTable, schema: cashFlowStatementSchema},
+		stockholdersEquityTable:     &stagedTable{table: stockholdersEquityTable, schema: stockholdersEquitySchema},
+		comprehensiveIncomeTable:    &stagedTable{table: comprehensiveIncomeTable, schema: comprehensiveIncomeSchema},
+		parentheticalTable:          &stagedTable{table: parentheticalTable, schema: parentheticalSchema},
+		filingStatementsTable:       &stagedTable{table: filingStatementsTable, schema: filingStatementsSchema},
+		notesTable:                  &stagedTable{table: notesTable, schema: notesSchema},
+		factsTable:                  &stagedTable{table: factsTable, schema: factsSchema},
+		filingCoverTable:            &stagedTable{table: filingCoverTable, schema: filingCoverSchema},
+		standardizedFinancialsTable: &stagedTable{table: standardizedFinancialsTable, schema: standardizedFinancialsSchema},
+		reportedValuesTable:         &stagedTable{table: reportedValuesTable, schema: reportedValuesSchema},
+		validationTable:             &stagedTable{table: validationTable, schema: validationSchema},
+		quarantinedFilingsTable:     &stagedTable{table: quarantinedFilingsTable, schema: quarantinedFilingsSchema},
+		insiderOwnersTable:          &stagedTable{table: insiderOwnersTable, schema: insiderOwnersSchema},
+		insiderTransactionsTable:    &stagedTable{table: insiderTransactionsTable, schema: insiderTransactionsSchema},
+		insiderHoldingsTable:        &stagedTable{table: insiderHoldingsTable, schema: insiderHoldingsSchema},
+		institutionalFilingsTable:   &stagedTable{table: institutionalFilingsTable, schema: institutionalFilingsSchema},
+		institutionalHoldingsTable:  &stagedTable{table: institutionalHoldingsTable, schema: institutionalHoldingsSchema},
+		eventsTable:                 &stagedTable{table: eventsTable, schema: eventsSchema},
+		narrativeSectionsTable:      &stagedTable{table: narrativeSectionsTable, schema: narrativeSectionsSchema},
+		loadedFilingsTable:          &stagedTable{table: loadedFilingsTable, schema: loadedFilingsSchema},
+	}
+	loader.tables = []*stagedTable{loader.balanceSheetTable, loader.incomeStatementTable, loader.cashFlowStatementTable, loader.stockholdersEquityTable, loader.comprehensiveIncomeTable, loader.parentheticalTable, loader.filingStatementsTable, loader.notesTable, loader.factsTable, loader.filingCoverTable, loader.standardizedFinancialsTable, loader.reportedValuesTable, loader.validationTable, loader.quarantinedFilingsTable, loader.insiderOwnersTable, loader.insiderTransactionsTable, loader.insiderHoldingsTable, loader.institutionalFilingsTable, loader.institutionalHoldingsTable, loader.eventsTable, loader.narrativeSectionsTable, loader.loadedFilingsTable}
+	return loader
+}
+
+//filingBatchSize is how many filings are staged before their rows are written, keeping a run's load jobs per table
+//well under BigQuery's daily limit
+const filingBatchSize = 100
+
+//deleteFilingRowsStatement removes the rows of a table that came from a batch of filings
+const deleteFilingRowsStatement = "DELETE FROM `%s.SEC.%s` WHERE AccessionNumber IN UNNEST(@accessionNumbers)"
+
+//stagedTable holds the rows of a table's filings until the loader flushes them
+type stagedTable struct {
+	table  *bigquery.Table
+	schema bigquery.Schema
+	rows   []interface{}
+}
+
+//add stages a row struct, or a slice of them, like Inserter.Put takes
+func (t *stagedTable) add(rows interface{}) {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice {
+		t.rows = append(t.rows, rows)
+		return
+	}
+	for i := 0; i < value.Len(); i++ {
+		t.rows = append(t.rows, value.Index(i).Interface())
+	}
+}
+
+//LoadedFiling records that a filing was loaded, so refreshes can skip it
+type LoadedFiling struct {
+	CIK             string
+	AccessionNumber string
+	Form            string
+	DateFiled       string
+	FilingURL       string
+	LoadedAt        time.Time
+}
+
+//Load stages a filing's rows for the tables of its form, writing them with the rest of the batch once
+//filingBatchSize filings are staged. A filing is recorded as loaded when its rows are written, unless its documents
+//can't be parsed or it was quarantined, so a later run tries it again
+func (l *FilingLoader) Load(item FilingWorkItem) {
+	var loaded bool
+	switch {
+	case isOwnershipForm(item.Form):
+		loaded = l.loadOwnership(item)
+	case isInstitutionalHoldingsForm(item.Form):
+		loaded = l.loadInstitutionalHoldings(item)
+	case isEventForm(item.Form):
+		loaded = l.loadEvent(item)
+	default:
+		loaded = l.loadFinancialStatements(item)
+	}
+	l.accessionNumbers = append(l.accessionNumbers, AccessionNumber(item.FilingLoc))
+	if loaded {
+		l.loadedFilingsTable.add(LoadedFiling{CIK: item.CIK, AccessionNumber: AccessionNumber(item.FilingLoc), Form: item.Form, DateFiled: item.DateFiled, FilingURL: item.FilingDirectoryURL(), LoadedAt: time.Now()})
+	}
+	if len(l.accessionNumbers) >= filingBatchSize {
+		l.Flush()
+	}
+}
+
+//Flush writes the staged rows of every table with a load job, after deleting the rows an earlier run wrote for the
+//same filings, so a retried or reloaded filing replaces its rows instead of adding a copy of them. loaded_filings
+//is written last, a filing is only recorded once all of its rows are in
+func (l *FilingLoader) Flush() {
+	if len(l.accessionNumbers) == 0 {
+		return
+	}
+	for _, staged := range l.tables {
+		q := l.bq.Query(fmt.Sprintf(deleteFilingRowsStatement, l.projectName, staged.table.TableID))
+		q.Parameters = []bigquery.QueryParameter{{Name: "accessionNumbers", Value: l.accessionNumbers}}
+		if err := runStatement(l.ctx, q); err != nil {
+			fmt.Println("Can't delete earlier rows of", staged.table.TableID)
+			log.Fatal(err)
+		}
+		if len(staged.rows) > 0 {
+			if err := loadRows(l.ctx, staged.table, staged.schema, staged.rows, bigquery.WriteAppend); err != nil {
+				fmt.Println("Can't upload", staged.table.TableID)
+				log.Fatal(err)
+			}
+		}
+		staged.rows = nil
+	}
+	fmt.Println(len(l.accessionNumbers), "Filings Uploaded")
+	l.accessionNumbers = nil
+}
+
+//loadOwnership parses the ownership xml of a Form 3, 4 or 5 and stages its owners, transactions and holdings,
+//reporting whether it could be parsed
+func (l *FilingLoader) loadOwnership(item FilingWorkItem) bool {
+	fmt.Println(item.SubmissionURL())
+	document, err := ParseOwnershipDocument(GetReportSEC(l.client, l.userAgent, item.SubmissionURL()))
+	if err != nil {
+		fmt.Println("Can't parse ownership document", err)
+		return false
+	}
+	ownerRows, transactionRows, holdingRows := OwnershipRows(document, item.Year, item.Quarter, AccessionNumber(item.FilingLoc), item.Form, item.DateFiled)
+	fmt.Println("Ownership Document Parsed")
+	l.insiderOwnersTable.add(ownerRows)
+	l.insiderTransactionsTable.add(transactionRows)
+	l.insiderHoldingsTable.add(holdingRows)
+	return true
+}
+
+//loadInstitutionalHoldings parses the cover page and information table of a 13F-HR and stages them, reporting
+//whether it could be parsed
+func (l *FilingLoader) loadInstitutionalHoldings(item FilingWorkItem) bool {
+	fmt.Println(item.SubmissionURL())
+	cover, table, err := Parse13F(GetReportSEC(l.client, l.userAgent, item.SubmissionURL()))
+	if err != nil {
+		fmt.Println("Can't parse 13F", err)
+		return false
+	}
+	filingRow, holdingRows := InstitutionalRows(cover, table, item.Year, item.Quarter, item.CIK, AccessionNumber(item.FilingLoc), item.Form, item.DateFiled)
+	fmt.Println("13F Parsed")
+	l.institutionalFilingsTable.add(filingRow)
+	l.institutionalHoldingsTable.add(holdingRows)
+	return true
+}
+
+//loadEvent parses the items, text and press release of an 8-K and stages its events row
+func (l *FilingLoader) loadEvent(item FilingWorkItem) bool {
+	fmt.Println(item.SubmissionURL())
+	eventRow := ParseEventFiling(GetReportSEC(l.client, l.userAgent, item.SubmissionURL()), item.Year, item.Quarter, item.CIK, item.CompanyName, AccessionNumber(item.FilingLoc), item.Form, item.DateFiled)
+	fmt.Println("8-K Parsed", eventRow.Items)
+	l.eventsTable.add(eventRow)
+	return true
+}
+
+//loadFinancialStatements parses a filing's statements, notes and cover page and stages them, along with the checks
+//run on them, reporting whether the filing was loaded rather than quarantined
+func (l *FilingLoader) loadFinancialStatements(item FilingWorkItem) bool {
+	year, qtr, cik := item.Year, item.Quarter, item.CIK
+	//Catalog the reports in the Filing Summary and find the xbrl formatted financial statements
+	filingDirectoryIndexURL := item.FilingDirectoryURL()
+	fmt.Println(filingDirectoryIndexURL)
+	filingSummaryURL := filingDirectoryIndexURL + "/FilingSummary.xml"
+	resp, filingSummary := GetRequestSEC(l.client, l.userAgent, filingSummaryURL)
+	filingSummaryFile, _ := io.ReadAll(filingSummary)
+	var filingSummaryObject FilingSummary
+	err := xml.Unmarshal(filingSummaryFile, &filingSummaryObject)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Close()
+	filingSummary.Close()
+	catalog := ParseFilingSummary(filingSummaryObject, filingDirectoryIndexURL, l.statementRules)
+	for _, candidate := range catalog.Statements {
+		fmt.Println(candidate.Statement, candidate.Report.LongName, candidate.Confidence)
+	}
+	accessionNumber := AccessionNumber(item.FilingLoc)
+	filingStatementRows := catalog.StatementCoverage(year, qtr, cik, accessionNumber)
+
+	//Parse the cover page for the dei facts of the filing
+	var filingCoverRows []FilingCover
+	if coverReports := catalog.Category(ReportCover); len(coverReports) > 0 {
+		fmt.Println(coverReports[0].URL)
+		filingCoverRows = append(filingCoverRows, ParseCoverPage(GetReportSEC(l.client, l.userAgent, coverReports[0].URL), year, qtr, cik, accessionNumber))
+		fmt.Println("Cover Page Parsed")
+	}
+	//Read the SEC header for the fiscal year end used when the cover page doesn't give one
+	secHeader := ParseSECHeader(GetReportSEC(l.client, l.userAgent, SECHeaderURL(filingDirectoryIndexURL, accessionNumber)))
+	var filingCover FilingCover
+	if len(filingCoverRows) > 0 {
+		filingCover = filingCoverRows[0]
+	}
+	fiscalCalendar := NewFiscalCalendar(filingCover, secHeader)
+	//Segment the primary document into its items (Business, Risk Factors, MD&A...) as clean text
+	var narrativeRows []NarrativeSection
+	if documentName, ok := PrimaryDocumentName(GetReportSEC(l.client, l.userAgent, FilingIndexURL(filingDirectoryIndexURL, accessionNumber)), item.Form); ok {
+		var periodOfReport bigquery.NullDate
+		if secHeader.PeriodOfReport.IsValid() {
+			periodOfReport = nullDate(secHeader.PeriodOfReport)
+		}
+		narrativeRows = ParseNarrativeSections(GetReportSEC(l.client, l.userAgent, filingDirectoryIndexURL+"/"+documentName), documentName, year, qtr, cik, accessionNumber, item.Form, item.DateFiled, periodOfReport)
+		fmt.Println("Narrative Sections Parsed")
+	}
+
+	//Parse Balance Sheet
+	var balanceSheetRows []BalanceSheetItem
+	if balanceSheet, ok := catalog.Statement(StatementBalanceSheet); ok {
+		fmt.Println(balanceSheet.URL)
+		balanceSheetRows = ParseBalanceSheet(GetReportSEC(l.client, l.userAgent, balanceSheet.URL), year, qtr, cik, accessionNumber)
+		fmt.Println("Balance Sheet Parsed")
+	}
+	//Parse Income Statement
+	var incomeStatementRows []IncomeOrCashFlowStatementItem
+	if incomeStatement, ok := catalog.Statement(StatementIncomeStatement); ok {
+		fmt.Println(incomeStatement.URL)
+		incomeStatementRows = ParseIncomeOrCashFlowStatement(GetReportSEC(l.client, l.userAgent, incomeStatement.URL), year, qtr, cik, accessionNumber)
+		fmt.Println("Income Statement Parsed")
+	}
+	//Parse Cash Flow Statement
+	var cashFlowStatementRows []IncomeOrCashFlowStatementItem
+	if cashFlowStatement, ok := catalog.Statement(StatementCashFlowStatement); ok {
+		fmt.Println(cashFlowStatement.URL)
+		cashFlowStatementRows = ParseIncomeOrCashFlowStatement(GetReportSEC(l.client, l.userAgent, cashFlowStatement.URL), year, qtr, cik, accessionNumber)
+		fmt.Println("Cash Flow Statement Parsed")
+	}
+	//Parse Statement of Stockholders' Equity, not every filing includes one
+	var stockholdersEquityRows []StockholdersEquityItem
+	if stockholdersEquity, ok := catalog.Statement(StatementStockholdersEquity); ok {
+		fmt.Println(stockholdersEquity.URL)
+		stockholdersEquityRows = ParseStockholdersEquityStatement(GetReportSEC(l.client, l.userAgent, stockholdersEquity.URL), year, qtr, cik, accessionNumber)
+		fmt.Println("Stockholders' Equity Statement Parsed")
+	}
+	//Parse Statement of Comprehensive Income, which is the income statement itself for combined statements
+	var comprehensiveIncomeRows []IncomeOrCashFlowStatementItem
+	if comprehensiveIncome, ok := catalog.Statement(StatementComprehensiveIncome); ok {
+		fmt.Println(comprehensiveIncome.URL)
+		comprehensiveIncomeRows = ParseIncomeOrCashFlowStatement(GetReportSEC(l.client, l.userAgent, comprehensiveIncome.URL), year, qtr, cik, accessionNumber)
+		fmt.Println("Comprehensive Income Statement Parsed")
+	}
+	//Parse parenthetical disclosures of every statement
+	var parentheticalRows []ParentheticalItem
+	for _, parenthetical := range catalog.Statements.All(StatementParenthetical) {
+		fmt.Println(parenthetical.URL)
+		parentheticalRows = append(parentheticalRows, ParseParenthetical(GetReportSEC(l.client, l.userAgent, parenthetical.URL), year, qtr, cik, accessionNumber)...)
+	}
+	fmt.Println("Parentheticals Parsed")
+	//Extract the narrative text blocks of the notes and accounting policies
+	var noteRows []NoteItem
+	for _, report := range append(catalog.Category(ReportNote), catalog.Category(ReportPolicy)...) {
+		noteRows = append(noteRows, ParseNotes(GetReportSEC(l.client, l.userAgent, report.URL), report, year, qtr, cik, accessionNumber)...)
+	}
+	fmt.Println("Notes Parsed")
+	//Load the numeric tables behind the notes (Details reports) as long-format facts
+	var factRows []FactItem
+	for _, report := range catalog.Category(ReportDetails) {
+		factRows = append(factRows, ParseDetailsReport(GetReportSEC(l.client, l.userAgent, report.URL), report, year, qtr, cik, accessionNumber)...)
+	}
+	fmt.Println("Details Parsed")
+	//Tag every row with the fiscal period it reports, the index year and quarter are only when it was filed
+	for i := range balanceSheetRows {
+		balanceSheetRows[i].FiscalYear, balanceSheetRows[i].FiscalQuarter = fiscalCalendar.Assign(bigquery.NullDate{}, balanceSheetRows[i].PeriodEnd, 0)
+	}
+	for _, rows := range [][]IncomeOrCashFlowStatementItem{incomeStatementRows, cashFlowStatementRows, comprehensiveIncomeRows} {
+		for i := range rows {
+			rows[i].FiscalYear, rows[i].FiscalQuarter = fiscalCalendar.Assign(rows[i].PeriodStart, rows[i].PeriodEnd, rows[i].PeriodMonths)
+		}
+	}
+	for i := range stockholdersEquityRows {
+		stockholdersEquityRows[i].FiscalYear, stockholdersEquityRows[i].FiscalQuarter = fiscalCalendar.Assign(stockholdersEquityRows[i].PeriodStart, stockholdersEquityRows[i].PeriodEnd, 0)
+	}
+	for i := range parentheticalRows {
+		parentheticalRows[i].FiscalYear, parentheticalRows[i].FiscalQuarter = fiscalCalendar.Assign(parentheticalRows[i].PeriodStart, parentheticalRows[i].PeriodEnd, parentheticalRows[i].PeriodMonths)
+	}
+	for i := range factRows {
+		factRows[i].FiscalYear, factRows[i].FiscalQuarter = fiscalCalendar.Assign(factRows[i].PeriodStart, factRows[i].PeriodEnd, factRows[i].PeriodMonths)
+	}
+	//Keep every reported value with the filing it came from to track restatements, noting the values the statement
+	//shows negated so the XBRL fact's sign can be restored
+	negatedLabels := make(NegatedLabels)
+	if presentationFile, ok := PresentationLinkbaseFile(filingSummaryObject); ok {
+		negatedLabels = ParsePresentationLinkbase(GetReportSEC(l.client, l.userAgent, filingDirectoryIndexURL+"/"+presentationFile))
+	}
+	negated := func(statement string) map[string]bool {
+		if report, ok := catalog.Statement(statement); ok {
+			return negatedLabels[report.Report.Role]
+		}
+		return nil
+	}
+	filingReport := NewFilingReport(cik, accessionNumber, item.Form, item.DateFiled, secHeader, filingCover)
+	reportedValueRows := BalanceSheetReportedValues(filingReport, negated(StatementBalanceSheet), balanceSheetRows)
+	reportedValueRows = append(reportedValueRows, IncomeOrCashFlowReportedValues(filingReport, StatementIncomeStatement, negated(StatementIncomeStatement), incomeStatementRows)...)
+	reportedValueRows = append(reportedValueRows, IncomeOrCashFlowReportedValues(filingReport, StatementCashFlowStatement, negated(StatementCashFlowStatement), cashFlowStatementRows)...)
+	reportedValueRows = append(reportedValueRows, IncomeOrCashFlowReportedValues(filingReport, StatementComprehensiveIncome, negated(StatementComprehensiveIncome), comprehensiveIncomeRows)...)
+
+	//Map the statements onto the standardized chart of accounts, using the filing's calculation
+	//linkbase to place company extension concepts under the total they roll up into
+	calculations := make(CalculationLinkbase)
+	if calculationFile, ok := CalculationLinkbaseFile(filingSummaryObject); ok {
+		calculations = ParseCalculationLinkbase(GetReportSEC(l.client, l.userAgent, filingDirectoryIndexURL+"/"+calculationFile))
+	}
+	var statementFacts []StatementFact
+	if balanceSheet, ok := catalog.Statement(StatementBalanceSheet); ok {
+		statementFacts = append(statementFacts, BalanceSheetFacts(balanceSheet.Report.Role, balanceSheetRows)...)
+	}
+	if incomeStatement, ok := catalog.Statement(StatementIncomeStatement); ok {
+		statementFacts = append(statementFacts, IncomeOrCashFlowFacts(StatementIncomeStatement, incomeStatement.Report.Role, incomeStatementRows)...)
+	}
+	if cashFlowStatement, ok := catalog.Statement(StatementCashFlowStatement); ok {
+		statementFacts = append(statementFacts, IncomeOrCashFlowFacts(StatementCashFlowStatement, cashFlowStatement.Report.Role, cashFlowStatementRows)...)
+	}
+	standardizedRows := Standardize(statementFacts, calculations, l.mappingRules, year, qtr, cik, accessionNumber)
+	fmt.Println("Statements Standardized")
+
+	//Check the accounting identities, filings failing them are held back when quarantining
+	validationRows := ValidateFiling(standardizedRows, year, qtr, cik, accessionNumber)
+	l.validationTable.add(validationRows)
+	l.filingStatementsTable.add(filingStatementRows)
+	l.narrativeSectionsTable.add(narrativeRows)
+	if failedChecks := FailedChecks(validationRows); len(failedChecks) > 0 && l.quarantine {
+		fmt.Println("Filing quarantined:", failedChecks)
+		l.quarantinedFilingsTable.add([]QuarantinedFiling{{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, FailedChecks: failedChecks}})
+		return false
+	}
+
+	//Stage financial data for BigQuery
+	l.balanceSheetTable.add(balanceSheetRows)
+	l.incomeStatementTable.add(incomeStatementRows)
+	l.cashFlowStatementTable.add(cashFlowStatementRows)
+	l.stockholdersEquityTable.add(stockholdersEquityRows)
+	l.comprehensiveIncomeTable.add(comprehensiveIncomeRows)
+	l.parentheticalTable.add(parentheticalRows)
+	l.notesTable.add(noteRows)
+	l.factsTable.add(factRows)
+	l.filingCoverTable.add(filingCoverRows)
+	l.reportedValuesTable.add(reportedValueRows)
+	l.standardizedFinancialsTable.add(standardizedRows)
+	return true
+}
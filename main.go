@@ -4,17 +4,14 @@ import (
 	"bufio"
 	"compress/gzip"
 	"context"
-	"encoding/csv"
 	"encoding/json"
-	"encoding/xml"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
-	"regexp"
-	"strings"
 
 	"cloud.google.com/go/bigquery"
 	"golang.org/x/time/rate"
@@ -60,7 +57,46 @@ func GetRequestSEC(c *RLHTTPClient, userAgent string, url string) (io.ReadCloser
 	return resp.Body, gzr
 }
 
+//GetReportSEC downloads a whole document from the SEC, such as an R page of a filing
+func GetReportSEC(c *RLHTTPClient, userAgent string, url string) []byte {
+	resp, body := GetRequestSEC(c, userAgent, url)
+	report, _ := io.ReadAll(body)
+	resp.Close()
+	body.Close()
+	return report
+}
+
 func main() {
+	//Commands working on what has already been loaded into BigQuery or on files downloaded from the SEC
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "derive":
+			deriveCommand(os.Args[2:])
+			return
+		case "point-in-time":
+			pointInTimeCommand(os.Args[2:])
+			return
+		case "metrics":
+			metricsCommand(os.Args[2:])
+			return
+		case "fsds":
+			fsdsCommand(os.Args[2:])
+			return
+		case "companyfacts":
+			companyFactsCommand(os.Args[2:])
+			return
+		case "companies":
+			companiesCommand(os.Args[2:])
+			return
+		case "watchlist":
+			watchlistCommand(os.Args[2:])
+			return
+		}
+	}
+	loaderOptions := addLoaderFlags(flag.CommandLine)
+	flag.Parse()
+	statementRules, mappingRules := loaderOptions.rules()
+
 	ratelimiter := rate.NewLimiter(10, 10)
 	c := NewClient(ratelimiter)
 	fmt.Println("Please enter in your Google Cloud project name: ")
@@ -90,21 +126,7 @@ func main() {
 	if err := ds.Create(ctx, &bigquery.DatasetMetadata{}); err != nil {
 		fmt.Println(err)
 	}
-	balanceSheetTable := ds.Table("balance-sheet")
-	balanceSheetSchema, _ := bigquery.InferSchema(BalanceSheetItem{})
-	if err := balanceSheetTable.Create(ctx, &bigquery.TableMetadata{Schema: balanceSheetSchema}); err != nil {
-		fmt.Println(err)
-	}
-	incomeStatementTable := ds.Table("income-statement")
-	incomeStatementSchema, _ := bigquery.InferSchema(IncomeOrCashFlowStatementItem{})
-	if err := incomeStatementTable.Create(ctx, &bigquery.TableMetadata{Schema: incomeStatementSchema}); err != nil {
-		fmt.Println(err)
-	}
-	cashFlowStatementTable := ds.Table("cash-flow-statement")
-	cashFlowStatementSchema, _ := bigquery.InferSchema(IncomeOrCashFlowStatementItem{})
-	if err := cashFlowStatementTable.Create(ctx, &bigquery.TableMetadata{Schema: cashFlowStatementSchema}); err != nil {
-		fmt.Println(err)
-	}
+	loader := NewFilingLoader(ctx, bq, ds, projectName, c, userAgent, statementRules, mappingRules, *loaderOptions.quarantine)
 	//Loop through each year
 	for _, yearItem := range years.Directory.Item {
 		//Testing for year 2020
@@ -140,41 +162,23 @@ func main() {
 					// 	log.Fatal(err)
 					// }
 
-					//Filter list for 10-Q and 10-K forms
-					pattern := regexp.MustCompile(`---*`)
-					loc := pattern.FindIndex(body)
-					headerRow := [][]string{
-						{"CIK", "CompanyName", "Form", "DateFiled", "FilingLoc"},
-					}
-					records := string(body)[loc[1]:]
-					r := csv.NewReader(strings.NewReader(records))
-					r.Comma = '|'
-					csv, _ := r.ReadAll()
-					df := append(headerRow, csv...)
-					financialStatementsList := make([][]string, 0)
-					for _, v := range df {
-						if v[2] == "10-Q" || v[2] == "10-K" {
-							financialStatementsList = append(financialStatementsList, v)
-						}
-					}
+					//Filter list for 10-Q and 10-K forms, amendments included as they replace earlier figures
+					financialStatementsList := XBRLIndexWorkItems(body, year, qtr)
 
 					j := 20
 					//Loop through filings
-					for i := range financialStatementsList {
-						financialStatementsLoc := financialStatementsList[i][4]
-						cik := financialStatementsList[i][0]
-
+					for i, item := range financialStatementsList {
 						if i > j {
 							break
 						}
 
 						// //Save whole filing in txt file to google cloud storage
-						// completeFilingURL := "https://www.sec.gov/Archives/" + financialStatementsLoc
+						// completeFilingURL := "https://www.sec.gov/Archives/" + item.FilingLoc
 						// resp, filingTextFile := GetRequestSEC(c, userAgent, completeFilingURL)
 						// body, _ = ioutil.ReadAll(filingTextFile)
 						// resp.Close()
 						// xbrlList.Close()
-						// editedFilingLoc := strings.Replace(financialStatementsLoc, "edgar/data/", "", 1)
+						// editedFilingLoc := strings.Replace(item.FilingLoc, "edgar/data/", "", 1)
 						// pattern = regexp.MustCompile(`\d+\/`)
 						// loc = pattern.FindIndex([]byte(editedFilingLoc))
 						// filename := editedFilingLoc[loc[1]:]
@@ -187,65 +191,23 @@ func main() {
 						// 	log.Fatal(err)
 						// }
 
-						//Find xbrl formatted balance sheet, income statement, and cash flow statement in Filing Summary
-						filingDirectoryIndexURL := "https://www.sec.gov/Archives/" + strings.Replace(strings.Replace(financialStatementsLoc, "-", "", 2), ".txt", "", 1)
-						fmt.Println(filingDirectoryIndexURL)
-						filingSummaryURL := filingDirectoryIndexURL + "/FilingSummary.xml"
-						resp, filingSummary := GetRequestSEC(c, userAgent, filingSummaryURL)
-						filingSummaryFile, _ := io.ReadAll(filingSummary)
-						var filingSummaryObject FilingSummary
-						err := xml.Unmarshal(filingSummaryFile, &filingSummaryObject)
-						if err != nil {
-							log.Fatal(err)
-						}
-						resp.Close()
-						filingSummary.Close()
-						balanceSheetURL, incomeStatementURL, cashFlowStatementURL := ParseFilingSummary(filingSummaryObject, filingDirectoryIndexURL)
-
-						//Parse Balance Sheet
-						fmt.Println(balanceSheetURL, incomeStatementURL, cashFlowStatementURL)
-						// resp, balanceSheetHTML := GetRequestSEC(c, userAgent, balanceSheetURL)
-						// balanceSheet, _ := io.ReadAll(balanceSheetHTML)
-						// balanceSheetRows := ParseBalanceSheet(balanceSheet, year, qtr, cik)
-						// // fmt.Println(balanceSheetRows)
-						// resp.Close()
-						// balanceSheetHTML.Close()
-						// fmt.Println("Balance Sheet Parsed")
-						//Parse Income Statement
-						fmt.Println(incomeStatementURL)
-						resp, incomeStatementHTML := GetRequestSEC(c, userAgent, incomeStatementURL)
-						incomeStatement, _ := io.ReadAll(incomeStatementHTML)
-						incomeStatementRows := ParseIncomeOrCashFlowStatement(incomeStatement, year, qtr, cik)
-						resp.Close()
-						incomeStatementHTML.Close()
-						fmt.Println("Income Statement Parsed")
-						//Parse Cash Flow Statement
-						fmt.Println(cashFlowStatementURL)
-						resp, cashFlowStatementHTML := GetRequestSEC(c, userAgent, cashFlowStatementURL)
-						cashFlowStatement, _ := io.ReadAll(cashFlowStatementHTML)
-						cashFlowStatementRows := ParseIncomeOrCashFlowStatement(cashFlowStatement, year, qtr, cik)
-						resp.Close()
-						cashFlowStatementHTML.Close()
-						fmt.Println("Cash Flow Statement Parsed")
-						// //Upload financial data to BigQuery
-						// balanceSheetInserter := balanceSheetTable.Inserter()
-						// if err := balanceSheetInserter.Put(ctx, balanceSheetRows); err != nil {
-						// 	fmt.Println("Can't upload data balance sheet")
-						// 	log.Fatal(err)
-						// }
-						incomeStatementInserter := incomeStatementTable.Inserter()
-						if err := incomeStatementInserter.Put(ctx, incomeStatementRows); err != nil {
-							fmt.Println("Can't upload data income statement")
-							log.Fatal(err)
-						}
-						cashFlowStatementInserter := cashFlowStatementTable.Inserter()
-						if err := cashFlowStatementInserter.Put(ctx, cashFlowStatementRows); err != nil {
-							fmt.Println("Can't upload data cash flow statement")
-							log.Fatal(err)
+						loader.Load(item)
+					}
+
+					//Get list of all filings for the forms loaded without xbrl, like insider ownership reports
+					resp, masterList := GetRequestSEC(c, userAgent, indexURL+year+"/"+qtr+"/master.gz")
+					body, _ = ioutil.ReadAll(masterList)
+					resp.Close()
+					masterList.Close()
+					for i, item := range MasterIndexWorkItems(body, year, qtr) {
+						if i > j {
+							break
 						}
+						loader.Load(item)
 					}
 				}
 			}
 		}
 	}
+	loader.Flush()
 }
@@ -0,0 +1,412 @@
+//Package metrics computes financial ratios from the standardized accounts of a company's filings
+package metrics
+
+import (
+	"math"
+	"math/big"
+	"sort"
+
+	"cloud.google.com/go/civil"
+)
+
+//FormulaVersion is stored with every metric and must be bumped whenever a formula changes
+const FormulaVersion = "1"
+
+//Standardized accounts the formulas use, as named in standard_accounts.json
+const (
+	Revenue                 = "Revenue"
+	CostOfRevenue           = "CostOfRevenue"
+	GrossProfit             = "GrossProfit"
+	OperatingIncome         = "OperatingIncome"
+	InterestExpense         = "InterestExpense"
+	PretaxIncome            = "PretaxIncome"
+	IncomeTaxExpense        = "IncomeTaxExpense"
+	NetIncome               = "NetIncome"
+	EarningsPerShareDiluted = "EarningsPerShareDiluted"
+	CashAndEquivalents      = "CashAndEquivalents"
+	ShortTermInvestments    = "ShortTermInvestments"
+	AccountsReceivable      = "AccountsReceivable"
+	Inventory               = "Inventory"
+	CurrentAssets           = "CurrentAssets"
+	TotalAssets             = "TotalAssets"
+	AccountsPayable         = "AccountsPayable"
+	CurrentLiabilities      = "CurrentLiabilities"
+	ShortTermDebt           = "ShortTermDebt"
+	LongTermDebt            = "LongTermDebt"
+	TotalLiabilities        = "TotalLiabilities"
+	StockholdersEquity      = "StockholdersEquity"
+	TotalEquity             = "TotalEquity"
+	CashFromOperations      = "CashFromOperations"
+	CapitalExpenditures     = "CapitalExpenditures"
+)
+
+//statutoryTaxRate is used for NOPAT when the effective tax rate of a period can't be worked out
+const statutoryTaxRate = 0.21
+
+//dateTolerance is how many days apart period ends can be and still line up, for 52/53 week fiscal years
+const dateTolerance = 7
+
+//Fact is the value of a standardized account for one period. Balance sheet accounts are instants with a zero Start,
+//durations other than a quarter or a fiscal year are left out of the periods
+type Fact struct {
+	CIK           string
+	Account       string
+	Start         civil.Date
+	End           civil.Date
+	Months        int
+	FiscalYear    int
+	FiscalQuarter string
+	Value         *big.Rat
+}
+
+//Period is a quarter or fiscal year of a company with the accounts the ratios are computed from: the flows over
+//the period and the balances at its end and at its start (the end of the previous period)
+type Period struct {
+	CIK             string
+	FiscalYear      int
+	FiscalQuarter   string
+	Start           civil.Date
+	End             civil.Date
+	Months          int
+	Flows           map[string]float64
+	Balances        map[string]float64
+	OpeningBalances map[string]float64
+}
+
+//Metric is a computed ratio or amount for a period
+type Metric struct {
+	Name    string
+	Value   float64
+	Formula string
+}
+
+//Result is the metrics of one period
+type Result struct {
+	Period  Period
+	Metrics []Metric
+}
+
+func toFloat(value *big.Rat) float64 {
+	f, _ := value.Float64()
+	return f
+}
+
+func abs(days int) int {
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+//balancesAt returns the balances reported closest to a date, within the tolerance
+func balancesAt(balances map[civil.Date]map[string]float64, date civil.Date) map[string]float64 {
+	best, bestDistance := map[string]float64(nil), dateTolerance+1
+	for end, accounts := range balances {
+		if distance := abs(end.DaysSince(date)); distance < bestDistance {
+			best, bestDistance = accounts, distance
+		}
+	}
+	return best
+}
+
+//BuildPeriods groups the facts of a company into quarters (three month durations) and fiscal years, attaching the
+//closing balances at the end of each and the opening balances at the end of the period before. Quarterly flows
+//should be discrete quarters like those derive works out, since filings report Q4 and most cash flow quarters
+//only as year to date durations
+func BuildPeriods(facts []Fact) []Period {
+	type periodKey struct {
+		cik        string
+		start, end civil.Date
+	}
+	var periods []Period
+	index := make(map[periodKey]int)
+	balances := make(map[string]map[civil.Date]map[string]float64)
+	for _, fact := range facts {
+		if fact.Value == nil {
+			continue
+		}
+		if fact.Months == 0 {
+			if balances[fact.CIK] == nil {
+				balances[fact.CIK] = make(map[civil.Date]map[string]float64)
+			}
+			if balances[fact.CIK][fact.End] == nil {
+				balances[fact.CIK][fact.End] = make(map[string]float64)
+			}
+			balances[fact.CIK][fact.End][fact.Account] = toFloat(fact.Value)
+			continue
+		}
+		if fact.Months != 3 && fact.Months != 12 {
+			continue
+		}
+		key := periodKey{fact.CIK, fact.Start, fact.End}
+		i, ok := index[key]
+		if !ok {
+			i = len(periods)
+			index[key] = i
+			periods = append(periods, Period{CIK: fact.CIK, FiscalYear: fact.FiscalYear, FiscalQuarter: fact.FiscalQuarter, Start: fact.Start, End: fact.End, Months: fact.Months, Flows: make(map[string]float64)})
+		}
+		periods[i].Flows[fact.Account] = toFloat(fact.Value)
+	}
+	for i := range periods {
+		periods[i].Balances = balancesAt(balances[periods[i].CIK], periods[i].End)
+		periods[i].OpeningBalances = balancesAt(balances[periods[i].CIK], periods[i].Start.AddDays(-1))
+	}
+	sort.SliceStable(periods, func(i, j int) bool {
+		if periods[i].CIK != periods[j].CIK {
+			return periods[i].CIK < periods[j].CIK
+		}
+		if periods[i].End != periods[j].End {
+			return periods[i].End.Before(periods[j].End)
+		}
+		return periods[i].Months < periods[j].Months
+	})
+	return periods
+}
+
+//periodEnd identifies the periods of a company by their length and end date
+type periodEnd struct {
+	cik    string
+	months int
+	end    civil.Date
+}
+
+//PeriodIndex looks up the periods of a company by length and end date
+type PeriodIndex map[periodEnd]int
+
+//IndexPeriods indexes periods for PriorYear
+func IndexPeriods(periods []Period) PeriodIndex {
+	index := make(PeriodIndex, len(periods))
+	for i, period := range periods {
+		index[periodEnd{period.CIK, period.Months, period.End}] = i
+	}
+	return index
+}
+
+//PriorYear returns the period of the same length ending a year before, used for growth rates. The closest end
+//date within the tolerance wins, as 52/53 week years don't end on the same day every year
+func (index PeriodIndex) PriorYear(periods []Period, period Period) (Period, bool) {
+	target := period.End.AddDays(-364)
+	for distance := 0; distance <= dateTolerance; distance++ {
+		for _, end := range []civil.Date{target.AddDays(-distance), target.AddDays(distance)} {
+			if i, ok := index[periodEnd{period.CIK, period.Months, end}]; ok {
+				return periods[i], true
+			}
+		}
+	}
+	return Period{}, false
+}
+
+//flow returns an account over the period
+func (p Period) flow(account string) (float64, bool) {
+	value, ok := p.Flows[account]
+	return value, ok
+}
+
+//balance returns an account at the end of the period
+func (p Period) balance(account string) (float64, bool) {
+	value, ok := p.Balances[account]
+	return value, ok
+}
+
+//average returns the mean of the opening and closing balance of an account, or the closing balance alone when
+//the opening one wasn't reported
+func (p Period) average(account string) (float64, bool) {
+	closing, ok := p.Balances[account]
+	if !ok {
+		return 0, false
+	}
+	if opening, ok := p.OpeningBalances[account]; ok {
+		return (opening + closing) / 2, true
+	}
+	return closing, true
+}
+
+//annualized scales a flow over a quarter to a year so returns are comparable between quarters and fiscal years
+func (p Period) annualized(value float64) float64 {
+	return value * 12 / float64(p.Months)
+}
+
+//days is the length of the period used by the working capital day counts
+func (p Period) days() float64 {
+	return 365 * float64(p.Months) / 12
+}
+
+//grossProfit is reported directly or worked out from revenue and cost of revenue. Costs are compared in absolute
+//value because R pages show them with whatever sign the filer's presentation uses
+func (p Period) grossProfit() (float64, bool) {
+	if value, ok := p.flow(GrossProfit); ok {
+		return value, true
+	}
+	revenue, ok := p.flow(Revenue)
+	cost, ok2 := p.flow(CostOfRevenue)
+	return revenue - math.Abs(cost), ok && ok2
+}
+
+//totalDebt adds up short and long term debt, either may be missing
+func (p Period) totalDebt() (float64, bool) {
+	shortTerm, ok := p.balance(ShortTermDebt)
+	longTerm, ok2 := p.balance(LongTermDebt)
+	return shortTerm + longTerm, ok || ok2
+}
+
+//taxRate is the effective tax rate of the period, or the statutory rate when it's meaningless
+func (p Period) taxRate() float64 {
+	tax, ok := p.flow(IncomeTaxExpense)
+	pretax, ok2 := p.flow(PretaxIncome)
+	if !ok || !ok2 || pretax <= 0 {
+		return statutoryTaxRate
+	}
+	rate := tax / pretax
+	if rate < 0 || rate > 1 {
+		return statutoryTaxRate
+	}
+	return rate
+}
+
+//investedCapital is equity plus debt less cash
+func (p Period) investedCapital(balances map[string]float64) (float64, bool) {
+	equity, ok := balances[TotalEquity]
+	if !ok {
+		if equity, ok = balances[StockholdersEquity]; !ok {
+			return 0, false
+		}
+	}
+	return equity + balances[ShortTermDebt] + balances[LongTermDebt] - balances[CashAndEquivalents], true
+}
+
+func ratio(numerator float64, denominator float64) (float64, bool) {
+	if denominator == 0 || math.IsNaN(numerator) || math.IsNaN(denominator) {
+		return 0, false
+	}
+	return numerator / denominator, true
+}
+
+//Compute returns the metrics of a period that its accounts allow, prior is the same period a year earlier for
+//growth rates and may be nil
+func Compute(p Period, prior *Period) []Metric {
+	var metrics []Metric
+	add := func(name string, formula string, value float64, ok bool) {
+		if ok && !math.IsInf(value, 0) && !math.IsNaN(value) {
+			metrics = append(metrics, Metric{Name: name, Value: value, Formula: formula})
+		}
+	}
+	revenue, hasRevenue := p.flow(Revenue)
+	netIncome, hasNetIncome := p.flow(NetIncome)
+	operatingIncome, hasOperatingIncome := p.flow(OperatingIncome)
+
+	//margins
+	if grossProfit, ok := p.grossProfit(); ok && hasRevenue {
+		value, ok := ratio(grossProfit, revenue)
+		add("GrossMargin", "GrossProfit / Revenue", value, ok)
+	}
+	if hasOperatingIncome && hasRevenue {
+		value, ok := ratio(operatingIncome, revenue)
+		add("OperatingMargin", "OperatingIncome / Revenue", value, ok)
+	}
+	if hasNetIncome && hasRevenue {
+		value, ok := ratio(netIncome, revenue)
+		add("NetMargin", "NetIncome / Revenue", value, ok)
+	}
+
+	//returns, on average balances with quarterly income annualized
+	if equity, ok := p.average(StockholdersEquity); ok && hasNetIncome {
+		value, ok := ratio(p.annualized(netIncome), equity)
+		add("ReturnOnEquity", "annualized NetIncome / average StockholdersEquity", value, ok)
+	}
+	if assets, ok := p.average(TotalAssets); ok && hasNetIncome {
+		value, ok := ratio(p.annualized(netIncome), assets)
+		add("ReturnOnAssets", "annualized NetIncome / average TotalAssets", value, ok)
+	}
+	if closing, ok := p.investedCapital(p.Balances); ok && hasOperatingIncome {
+		capital := closing
+		if opening, ok := p.investedCapital(p.OpeningBalances); ok {
+			capital = (opening + closing) / 2
+		}
+		value, ok := ratio(p.annualized(operatingIncome)*(1-p.taxRate()), capital)
+		add("ReturnOnInvestedCapital", "annualized OperatingIncome * (1 - effective tax rate) / average (TotalEquity + ShortTermDebt + LongTermDebt - CashAndEquivalents)", value, ok)
+	}
+
+	//liquidity
+	currentLiabilities, hasCurrentLiabilities := p.balance(CurrentLiabilities)
+	if currentAssets, ok := p.balance(CurrentAssets); ok && hasCurrentLiabilities {
+		value, ok := ratio(currentAssets, currentLiabilities)
+		add("CurrentRatio", "CurrentAssets / CurrentLiabilities", value, ok)
+	}
+	if cash, ok := p.balance(CashAndEquivalents); ok && hasCurrentLiabilities {
+		value, ok := ratio(cash+p.Balances[ShortTermInvestments]+p.Balances[AccountsReceivable], currentLiabilities)
+		add("QuickRatio", "(CashAndEquivalents + ShortTermInvestments + AccountsReceivable) / CurrentLiabilities", value, ok)
+	}
+
+	//leverage
+	if debt, ok := p.totalDebt(); ok {
+		if equity, ok := p.balance(StockholdersEquity); ok {
+			value, ok := ratio(debt, equity)
+			add("DebtToEquity", "(ShortTermDebt + LongTermDebt) / StockholdersEquity", value, ok)
+		}
+	}
+	if liabilities, ok := p.balance(TotalLiabilities); ok {
+		if assets, ok := p.balance(TotalAssets); ok {
+			value, ok := ratio(liabilities, assets)
+			add("LiabilitiesToAssets", "TotalLiabilities / TotalAssets", value, ok)
+		}
+	}
+	if interest, ok := p.flow(InterestExpense); ok && hasOperatingIncome {
+		value, ok := ratio(operatingIncome, math.Abs(interest))
+		add("InterestCoverage", "OperatingIncome / InterestExpense", value, ok)
+	}
+
+	//cash flow
+	if cfo, ok := p.flow(CashFromOperations); ok {
+		if capex, ok := p.flow(CapitalExpenditures); ok {
+			add("FreeCashFlow", "CashFromOperations - CapitalExpenditures", cfo-math.Abs(capex), true)
+		}
+	}
+
+	//working capital days, on average balances
+	receivableDays, hasReceivableDays := 0.0, false
+	if receivables, ok := p.average(AccountsReceivable); ok && hasRevenue {
+		receivableDays, hasReceivableDays = ratio(receivables*p.days(), revenue)
+		add("DaysSalesOutstanding", "average AccountsReceivable / Revenue * days", receivableDays, hasReceivableDays)
+	}
+	cost, hasCost := p.flow(CostOfRevenue)
+	inventoryDays, hasInventoryDays := 0.0, false
+	payableDays, hasPayableDays := 0.0, false
+	if inventory, ok := p.average(Inventory); ok && hasCost {
+		inventoryDays, hasInventoryDays = ratio(inventory*p.days(), math.Abs(cost))
+		add("DaysInventoryOutstanding", "average Inventory / CostOfRevenue * days", inventoryDays, hasInventoryDays)
+	}
+	if payables, ok := p.average(AccountsPayable); ok && hasCost {
+		payableDays, hasPayableDays = ratio(payables*p.days(), math.Abs(cost))
+		add("DaysPayableOutstanding", "average AccountsPayable / CostOfRevenue * days", payableDays, hasPayableDays)
+	}
+	add("CashConversionCycle", "DaysSalesOutstanding + DaysInventoryOutstanding - DaysPayableOutstanding", receivableDays+inventoryDays-payableDays, hasReceivableDays && hasInventoryDays && hasPayableDays)
+
+	//growth over the same period a year earlier
+	if prior != nil {
+		for _, account := range []string{Revenue, NetIncome, EarningsPerShareDiluted} {
+			current, ok := p.flow(account)
+			previous, ok2 := prior.flow(account)
+			if !ok || !ok2 {
+				continue
+			}
+			value, ok := ratio(current-previous, math.Abs(previous))
+			add(account+"Growth", "("+account+" - prior year "+account+") / |prior year "+account+"|", value, ok)
+		}
+	}
+	return metrics
+}
+
+//ComputeAll builds the periods of the facts and computes the metrics of each
+func ComputeAll(facts []Fact) []Result {
+	periods := BuildPeriods(facts)
+	index := IndexPeriods(periods)
+	results := make([]Result, 0, len(periods))
+	for _, period := range periods {
+		var prior *Period
+		if previous, ok := index.PriorYear(periods, period); ok {
+			prior = &previous
+		}
+		results = append(results, Result{Period: period, Metrics: Compute(period, prior)})
+	}
+	return results
+}
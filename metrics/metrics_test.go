@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+func day(year int, month time.Month, d int) civil.Date {
+	return civil.Date{Year: year, Month: month, Day: d}
+}
+
+func flowFact(account string, start civil.Date, end civil.Date, months int, fiscalYear int, fiscalQuarter string, value int64) Fact {
+	return Fact{CIK: "320193", Account: account, Start: start, End: end, Months: months, FiscalYear: fiscalYear, FiscalQuarter: fiscalQuarter, Value: big.NewRat(value, 1)}
+}
+
+func balanceFact(account string, end civil.Date, value int64) Fact {
+	return Fact{CIK: "320193", Account: account, End: end, Value: big.NewRat(value, 1)}
+}
+
+//appleFacts are Apple's fiscal 2018 and 2019 years (52 weeks ended Sep. 29, 2018 and Sep. 28, 2019) and the fourth
+//quarter of fiscal 2019, which derive works out from the year less nine months, in millions
+func appleFacts() []Fact {
+	return []Fact{
+		flowFact(Revenue, day(2017, time.October, 1), day(2018, time.September, 29), 12, 2018, "FY", 265595),
+		flowFact(NetIncome, day(2017, time.October, 1), day(2018, time.September, 29), 12, 2018, "FY", 59531),
+		flowFact(Revenue, day(2018, time.September, 30), day(2019, time.September, 28), 12, 2019, "FY", 260174),
+		flowFact(NetIncome, day(2018, time.September, 30), day(2019, time.September, 28), 12, 2019, "FY", 55256),
+		flowFact(Revenue, day(2019, time.June, 30), day(2019, time.September, 28), 3, 2019, "Q4", 64040),
+		flowFact(NetIncome, day(2019, time.June, 30), day(2019, time.September, 28), 3, 2019, "Q4", 13686),
+		//nine months to date durations are left out of the periods
+		flowFact(Revenue, day(2018, time.September, 30), day(2019, time.June, 29), 9, 2019, "Q3", 196134),
+		balanceFact(StockholdersEquity, day(2018, time.September, 29), 107147),
+		balanceFact(StockholdersEquity, day(2019, time.June, 29), 96456),
+		balanceFact(StockholdersEquity, day(2019, time.September, 28), 90488),
+		balanceFact(TotalAssets, day(2019, time.September, 28), 338516),
+		balanceFact(CurrentAssets, day(2019, time.September, 28), 162819),
+		balanceFact(CurrentLiabilities, day(2019, time.September, 28), 105718),
+	}
+}
+
+func metricValue(metrics []Metric, name string) (float64, bool) {
+	for _, metric := range metrics {
+		if metric.Name == name {
+			return metric.Value, true
+		}
+	}
+	return 0, false
+}
+
+func TestBuildPeriods(t *testing.T) {
+	periods := BuildPeriods(appleFacts())
+	if len(periods) != 3 {
+		t.Fatalf("got %d periods %+v, want fiscal 2018, Q4 2019 and fiscal 2019", len(periods), periods)
+	}
+	quarter := periods[1]
+	if quarter.FiscalQuarter != "Q4" || quarter.Months != 3 || quarter.Flows[Revenue] != 64040 {
+		t.Errorf("got %+v, want the derived fourth quarter", quarter)
+	}
+	if quarter.OpeningBalances[StockholdersEquity] != 96456 || quarter.Balances[StockholdersEquity] != 90488 {
+		t.Errorf("got opening %v closing %v, want the balances at the third and fourth quarter ends", quarter.OpeningBalances, quarter.Balances)
+	}
+	if year := periods[2]; year.OpeningBalances[StockholdersEquity] != 107147 {
+		t.Errorf("got opening %v, want the balances at the end of fiscal 2018", year.OpeningBalances)
+	}
+}
+
+func TestComputeAll(t *testing.T) {
+	tests := []struct {
+		name          string
+		fiscalQuarter string
+		metric        string
+		want          float64
+		ok            bool
+	}{
+		{"return on equity uses the average of the opening and closing balance", "FY", "ReturnOnEquity", 55256 / ((107147 + 90488) / 2.0), true},
+		{"quarterly returns are annualized", "Q4", "ReturnOnEquity", 13686 * 4 / ((96456 + 90488) / 2.0), true},
+		{"return on assets falls back on the closing balance", "FY", "ReturnOnAssets", 55256 / 338516.0, true},
+		{"growth over the prior fiscal year", "FY", "RevenueGrowth", (260174 - 265595) / 265595.0, true},
+		{"no prior year quarter, no growth", "Q4", "RevenueGrowth", 0, false},
+		{"current ratio", "FY", "CurrentRatio", 162819 / 105718.0, true},
+		{"the first year has no closing balances", "FY2018", "CurrentRatio", 0, false},
+	}
+	results := ComputeAll(appleFacts())
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var metrics []Metric
+			for _, result := range results {
+				period := result.Period
+				if period.FiscalQuarter == test.fiscalQuarter && period.FiscalYear == 2019 || test.fiscalQuarter == "FY2018" && period.FiscalYear == 2018 {
+					metrics = result.Metrics
+				}
+			}
+			got, ok := metricValue(metrics, test.metric)
+			if ok != test.ok || math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("%s = %v %v, want %v %v", test.metric, got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestComputeSkipsUndefinedRatios(t *testing.T) {
+	period := Period{
+		CIK:      "320193",
+		Months:   3,
+		Flows:    map[string]float64{Revenue: 0, NetIncome: math.MaxFloat64, OperatingIncome: 50, InterestExpense: 0, EarningsPerShareDiluted: 1},
+		Balances: map[string]float64{StockholdersEquity: 0, TotalAssets: 1, CurrentAssets: 10, CurrentLiabilities: 0},
+	}
+	prior := Period{CIK: "320193", Months: 3, Flows: map[string]float64{Revenue: 0, EarningsPerShareDiluted: 0}}
+	metrics := Compute(period, &prior)
+	//a zero denominator, or annualized income overflowing to infinity over total assets
+	for _, name := range []string{"NetMargin", "OperatingMargin", "ReturnOnEquity", "ReturnOnAssets", "CurrentRatio", "InterestCoverage", "RevenueGrowth", "EarningsPerShareDilutedGrowth"} {
+		if value, ok := metricValue(metrics, name); ok {
+			t.Errorf("%s = %v, want it skipped", name, value)
+		}
+	}
+	for _, metric := range metrics {
+		if math.IsInf(metric.Value, 0) || math.IsNaN(metric.Value) {
+			t.Errorf("%s = %v, want no infinite or NaN metrics", metric.Name, metric.Value)
+		}
+	}
+}
+
+func TestPriorYear(t *testing.T) {
+	periods := []Period{
+		{CIK: "320193", Months: 12, End: day(2018, time.September, 29)},
+		{CIK: "320193", Months: 3, End: day(2018, time.September, 29)},
+		{CIK: "1326801", Months: 12, End: day(2018, time.September, 30)},
+		{CIK: "320193", Months: 12, End: day(2019, time.September, 28)},
+		{CIK: "320193", Months: 12, End: day(2022, time.September, 24)},
+	}
+	index := IndexPeriods(periods)
+	tests := []struct {
+		name   string
+		period Period
+		want   civil.Date
+		ok     bool
+	}{
+		{"52 week year", periods[3], day(2018, time.September, 29), true},
+		{"53 week year", Period{CIK: "320193", Months: 12, End: day(2023, time.September, 30)}, day(2022, time.September, 24), true},
+		{"another company's year doesn't count", Period{CIK: "789019", Months: 12, End: day(2019, time.September, 28)}, civil.Date{}, false},
+		{"a quarter isn't compared with a year", Period{CIK: "1326801", Months: 3, End: day(2019, time.September, 30)}, civil.Date{}, false},
+		{"nothing a year before", periods[0], civil.Date{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prior, ok := index.PriorYear(periods, test.period)
+			if ok != test.ok || prior.End != test.want || ok && prior.Months != test.period.Months {
+				t.Errorf("got %+v %v, want the year ended %v", prior, ok, test.want)
+			}
+		})
+	}
+}
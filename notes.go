@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/anaskhan96/soup"
+	"golang.org/x/net/html"
+)
+
+type NoteItem struct {
+	Year            string
+	Quarter         string
+	CIK             string
+	AccessionNumber string
+	Category        string
+	ReportName      string
+	Role            string
+	Concept         string
+	Label           string
+	Text            string
+	HTML            string
+}
+
+//allowedNoteTags are the elements kept when sanitizing a text block, anything else is unwrapped to its children
+var allowedNoteTags = map[string]bool{
+	"p": true, "div": true, "span": true, "br": true, "b": true, "strong": true, "i": true, "em": true, "u": true,
+	"ul": true, "ol": true, "li": true, "table": true, "thead": true, "tbody": true, "tr": true, "td": true, "th": true,
+	"sup": true, "sub": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+//droppedNoteTags are removed along with everything inside them, ix:header holds the hidden facts of inline xbrl documents
+var droppedNoteTags = map[string]bool{"script": true, "style": true, "iframe": true, "object": true, "embed": true, "head": true, "ix:header": true}
+
+//blockNoteTags end a line in the clean text of a text block
+var blockNoteTags = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "table": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+var (
+	spacesPattern   = regexp.MustCompile(`[ \t\x{00a0}]+`)
+	newlinesPattern = regexp.MustCompile(`\s*\n\s*`)
+)
+
+//sanitizeNoteHTML keeps the structure of a text block (paragraphs, lists, tables) while dropping scripts,
+//styles and every attribute other than the table spans
+func sanitizeNoteHTML(node *html.Node) string {
+	var buf bytes.Buffer
+	var sanitize func(*html.Node)
+	sanitize = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				buf.WriteString(html.EscapeString(child.Data))
+			case html.ElementNode:
+				tag := strings.ToLower(child.Data)
+				if droppedNoteTags[tag] {
+					continue
+				}
+				if !allowedNoteTags[tag] {
+					sanitize(child)
+					continue
+				}
+				buf.WriteString("<" + tag)
+				for _, attr := range child.Attr {
+					if attr.Key == "colspan" || attr.Key == "rowspan" {
+						buf.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+					}
+				}
+				buf.WriteString(">")
+				if tag == "br" {
+					continue
+				}
+				sanitize(child)
+				buf.WriteString("</" + tag + ">")
+			}
+		}
+	}
+	sanitize(node)
+	return buf.String()
+}
+
+//noteText flattens a text block to plain text with a line per paragraph or table row
+func noteText(node *html.Node) string {
+	var buf bytes.Buffer
+	var flatten func(*html.Node)
+	flatten = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				buf.WriteString(child.Data)
+			case html.ElementNode:
+				tag := strings.ToLower(child.Data)
+				if droppedNoteTags[tag] {
+					continue
+				}
+				flatten(child)
+				if tag == "td" || tag == "th" {
+					buf.WriteString("\t")
+				}
+				if blockNoteTags[tag] {
+					buf.WriteString("\n")
+				}
+			}
+		}
+	}
+	flatten(node)
+	text := spacesPattern.ReplaceAllString(buf.String(), " ")
+	text = newlinesPattern.ReplaceAllString(text, "\n")
+	return strings.TrimSpace(text)
+}
+
+//ParseNotes extracts the narrative text blocks (e.g. us-gaap:DebtDisclosureTextBlock or the policy text blocks)
+//of a Notes or Policies report as clean text and sanitized html
+func ParseNotes(notes []byte, report CatalogReport, year string, qtr string, cik string, accessionNumber string) []NoteItem {
+	doc := soup.HTMLParse(string(notes))
+	table := doc.Find("table")
+	if table.Error != nil {
+		return nil
+	}
+	var noteRows []NoteItem
+	for _, row := range table.FindAll("tr") {
+		label := row.Find("td", "class", "pl")
+		if label.Error != nil {
+			continue
+		}
+		link := label.Find("a")
+		if link.Error != nil {
+			continue
+		}
+		xbrlTag := strings.Replace(strings.Replace(link.Attrs()["onclick"], "top.Show.showAR( this, '", "", 1), "', window );", "", 1)
+		if !strings.HasSuffix(xbrlTag, "TextBlock") {
+			continue
+		}
+		for _, cell := range row.FindAll("td", "class", "text") {
+			text := noteText(cell.Pointer)
+			if text == "" {
+				continue
+			}
+			noteRow := NoteItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, Category: report.Category, ReportName: report.Name, Role: report.Role, Concept: xbrlConcept(xbrlTag), Label: strings.TrimSpace(label.FullText()), Text: text, HTML: sanitizeNoteHTML(cell.Pointer)}
+			noteRows = append(noteRows, noteRow)
+			break
+		}
+	}
+	return noteRows
+}
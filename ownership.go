@@ -0,0 +1,304 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+//isOwnershipForm reports whether a form is an insider ownership report, Forms 3, 4 and 5 and their amendments
+func isOwnershipForm(form string) bool {
+	switch strings.TrimSuffix(form, "/A") {
+	case "3", "4", "5":
+		return true
+	}
+	return false
+}
+
+//ownershipValue is an ownership document field, a value with the footnotes it references
+type ownershipValue struct {
+	Value     string `xml:"value"`
+	Footnotes []struct {
+		ID string `xml:"id,attr"`
+	} `xml:"footnoteId"`
+}
+
+//ownershipAmounts holds the amounts of a transaction or holding, transactions report shares or, for some
+//derivatives, a total value
+type ownershipAmounts struct {
+	Shares          ownershipValue `xml:"transactionShares"`
+	TotalValue      ownershipValue `xml:"transactionTotalValue"`
+	PricePerShare   ownershipValue `xml:"transactionPricePerShare"`
+	AcquiredOrSold  ownershipValue `xml:"transactionAcquiredDisposedCode"`
+	SharesFollowing ownershipValue `xml:"sharesOwnedFollowingTransaction"`
+	ValueFollowing  ownershipValue `xml:"valueOwnedFollowingTransaction"`
+}
+
+//ownershipEntry is a transaction or holding of the non-derivative or derivative table
+type ownershipEntry struct {
+	SecurityTitle             ownershipValue `xml:"securityTitle"`
+	ConversionOrExercisePrice ownershipValue `xml:"conversionOrExercisePrice"`
+	TransactionDate           ownershipValue `xml:"transactionDate"`
+	DeemedExecutionDate       ownershipValue `xml:"deemedExecutionDate"`
+	Coding                    struct {
+		FormType           string `xml:"transactionFormType"`
+		Code               string `xml:"transactionCode"`
+		EquitySwapInvolved string `xml:"equitySwapInvolved"`
+		Footnotes          []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"footnoteId"`
+	} `xml:"transactionCoding"`
+	Timeliness         ownershipValue   `xml:"transactionTimeliness"`
+	TransactionAmounts ownershipAmounts `xml:"transactionAmounts"`
+	ExerciseDate       ownershipValue   `xml:"exerciseDate"`
+	ExpirationDate     ownershipValue   `xml:"expirationDate"`
+	Underlying         struct {
+		Title  ownershipValue `xml:"underlyingSecurityTitle"`
+		Shares ownershipValue `xml:"underlyingSecurityShares"`
+		Value  ownershipValue `xml:"underlyingSecurityValue"`
+	} `xml:"underlyingSecurity"`
+	PostTransactionAmounts ownershipAmounts `xml:"postTransactionAmounts"`
+	Nature                 struct {
+		DirectOrIndirect ownershipValue `xml:"directOrIndirectOwnership"`
+		Nature           ownershipValue `xml:"natureOfOwnership"`
+	} `xml:"ownershipNature"`
+}
+
+//OwnershipDocument is the xml of a Form 3, 4 or 5
+type OwnershipDocument struct {
+	SchemaVersion         string `xml:"schemaVersion"`
+	DocumentType          string `xml:"documentType"`
+	PeriodOfReport        string `xml:"periodOfReport"`
+	NotSubjectToSection16 string `xml:"notSubjectToSection16"`
+	Issuer                struct {
+		CIK           string `xml:"issuerCik"`
+		Name          string `xml:"issuerName"`
+		TradingSymbol string `xml:"issuerTradingSymbol"`
+	} `xml:"issuer"`
+	ReportingOwners []struct {
+		CIK          string `xml:"reportingOwnerId>rptOwnerCik"`
+		Name         string `xml:"reportingOwnerId>rptOwnerName"`
+		City         string `xml:"reportingOwnerAddress>rptOwnerCity"`
+		State        string `xml:"reportingOwnerAddress>rptOwnerState"`
+		Relationship struct {
+			IsDirector        string `xml:"isDirector"`
+			IsOfficer         string `xml:"isOfficer"`
+			IsTenPercentOwner string `xml:"isTenPercentOwner"`
+			IsOther           string `xml:"isOther"`
+			OfficerTitle      string `xml:"officerTitle"`
+			OtherText         string `xml:"otherText"`
+		} `xml:"reportingOwnerRelationship"`
+	} `xml:"reportingOwner"`
+	NonDerivativeTransactions []ownershipEntry `xml:"nonDerivativeTable>nonDerivativeTransaction"`
+	NonDerivativeHoldings     []ownershipEntry `xml:"nonDerivativeTable>nonDerivativeHolding"`
+	DerivativeTransactions    []ownershipEntry `xml:"derivativeTable>derivativeTransaction"`
+	DerivativeHoldings        []ownershipEntry `xml:"derivativeTable>derivativeHolding"`
+	Footnotes                 []struct {
+		ID   string `xml:"id,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"footnotes>footnote"`
+	Remarks string `xml:"remarks"`
+}
+
+//InsiderReportingOwner is a person or entity reporting on a Form 3, 4 or 5 with their relationship to the issuer
+type InsiderReportingOwner struct {
+	Year              string
+	Quarter           string
+	IssuerCIK         string
+	IssuerName        string
+	IssuerTicker      string
+	AccessionNumber   string
+	Form              string
+	FilingDate        bigquery.NullDate
+	PeriodOfReport    bigquery.NullDate
+	OwnerCIK          string
+	OwnerName         string
+	OwnerCity         string
+	OwnerState        string
+	IsDirector        bool
+	IsOfficer         bool
+	IsTenPercentOwner bool
+	IsOther           bool
+	OfficerTitle      string
+	OtherText         string
+}
+
+//InsiderTransaction is a purchase, sale, grant, exercise or other change in an insider's holding of a security.
+//The derivative fields are only set on derivative (option, warrant, convertible) transactions
+type InsiderTransaction struct {
+	Year                      string
+	Quarter                   string
+	IssuerCIK                 string
+	IssuerName                string
+	IssuerTicker              string
+	AccessionNumber           string
+	Form                      string
+	FilingDate                bigquery.NullDate
+	PeriodOfReport            bigquery.NullDate
+	OwnerCIKs                 []string
+	OwnerNames                []string
+	IsDerivative              bool
+	SecurityTitle             string
+	TransactionDate           bigquery.NullDate
+	DeemedExecutionDate       bigquery.NullDate
+	TransactionFormType       string
+	TransactionCode           string
+	EquitySwapInvolved        bool
+	Timeliness                string
+	Shares                    *big.Rat `bigquery:",nullable"`
+	TotalValue                *big.Rat `bigquery:",nullable"`
+	PricePerShare             *big.Rat `bigquery:",nullable"`
+	AcquiredOrDisposed        string
+	SharesOwnedFollowing      *big.Rat `bigquery:",nullable"`
+	ValueOwnedFollowing       *big.Rat `bigquery:",nullable"`
+	DirectOrIndirect          string
+	NatureOfOwnership         string
+	ConversionOrExercisePrice *big.Rat `bigquery:",nullable"`
+	ExerciseDate              bigquery.NullDate
+	ExpirationDate            bigquery.NullDate
+	UnderlyingSecurityTitle   string
+	UnderlyingShares          *big.Rat `bigquery:",nullable"`
+	Footnotes                 []string
+}
+
+//InsiderHolding is a security an insider holds without a reported transaction, as listed on Forms 3 and 5 and
+//for indirect holdings on Form 4
+type InsiderHolding struct {
+	Year                      string
+	Quarter                   string
+	IssuerCIK                 string
+	IssuerName                string
+	IssuerTicker              string
+	AccessionNumber           string
+	Form                      string
+	FilingDate                bigquery.NullDate
+	PeriodOfReport            bigquery.NullDate
+	OwnerCIKs                 []string
+	OwnerNames                []string
+	IsDerivative              bool
+	SecurityTitle             string
+	SharesOwned               *big.Rat `bigquery:",nullable"`
+	ValueOwned                *big.Rat `bigquery:",nullable"`
+	DirectOrIndirect          string
+	NatureOfOwnership         string
+	ConversionOrExercisePrice *big.Rat `bigquery:",nullable"`
+	ExerciseDate              bigquery.NullDate
+	ExpirationDate            bigquery.NullDate
+	UnderlyingSecurityTitle   string
+	UnderlyingShares          *big.Rat `bigquery:",nullable"`
+	Footnotes                 []string
+}
+
+//ParseOwnershipDocument finds the ownership xml in a filing's complete submission text file and reads it
+func ParseOwnershipDocument(submission []byte) (OwnershipDocument, error) {
+	var document OwnershipDocument
+	for _, submissionDocument := range ParseSubmissionDocuments(submission) {
+		text := submissionDocument.XML()
+		if !strings.Contains(text, "<ownershipDocument") {
+			continue
+		}
+		err := decodeSubmissionXML(text, &document)
+		return document, err
+	}
+	return document, errors.New("no ownership document in filing")
+}
+
+//ownershipBool reads the 1/0 and true/false flags of ownership documents
+func ownershipBool(value string) bool {
+	value = strings.TrimSpace(value)
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+//ownershipNumber reads a share count or price, nil when not reported
+func ownershipNumber(value ownershipValue) *big.Rat {
+	number, ok := new(big.Rat).SetString(strings.ReplaceAll(strings.TrimSpace(value.Value), ",", ""))
+	if !ok {
+		return nil
+	}
+	return number
+}
+
+//xmlDate reads a YYYY-MM-DD date, which some ownership filers give with a time zone offset like 2020-01-02-05:00
+func xmlDate(value string) bigquery.NullDate {
+	value = strings.TrimSpace(value)
+	if len(value) > 10 {
+		value = value[:10]
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return bigquery.NullDate{}
+	}
+	return nullDate(civil.DateOf(date))
+}
+
+//footnoteTexts resolves the footnotes referenced by the values of an entry, each once
+func (d OwnershipDocument) footnoteTexts(values ...ownershipValue) []string {
+	texts := make(map[string]string)
+	for _, footnote := range d.Footnotes {
+		texts[footnote.ID] = strings.Join(strings.Fields(footnote.Text), " ")
+	}
+	var footnotes []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, footnote := range value.Footnotes {
+			if text, ok := texts[footnote.ID]; ok && !seen[footnote.ID] {
+				seen[footnote.ID] = true
+				footnotes = append(footnotes, text)
+			}
+		}
+	}
+	return footnotes
+}
+
+//entryFootnotes are the footnotes referenced anywhere in a transaction or holding
+func (d OwnershipDocument) entryFootnotes(entry ownershipEntry) []string {
+	coding := ownershipValue{Footnotes: entry.Coding.Footnotes}
+	return d.footnoteTexts(entry.SecurityTitle, entry.ConversionOrExercisePrice, entry.TransactionDate, entry.DeemedExecutionDate, coding, entry.Timeliness,
+		entry.TransactionAmounts.Shares, entry.TransactionAmounts.TotalValue, entry.TransactionAmounts.PricePerShare, entry.TransactionAmounts.AcquiredOrSold,
+		entry.ExerciseDate, entry.ExpirationDate, entry.Underlying.Title, entry.Underlying.Shares, entry.Underlying.Value,
+		entry.PostTransactionAmounts.SharesFollowing, entry.PostTransactionAmounts.ValueFollowing, entry.Nature.DirectOrIndirect, entry.Nature.Nature)
+}
+
+//OwnershipRows turns an ownership document into its reporting owners, transactions and holdings. Transactions and
+//holdings are reported jointly by every reporting owner of the filing
+func OwnershipRows(document OwnershipDocument, year string, qtr string, accessionNumber string, form string, dateFiled string) ([]InsiderReportingOwner, []InsiderTransaction, []InsiderHolding) {
+	issuerCIK := normalizeCIK(document.Issuer.CIK)
+	filingDate := xmlDate(dateFiled)
+	periodOfReport := xmlDate(document.PeriodOfReport)
+	var ownerRows []InsiderReportingOwner
+	var ownerCIKs, ownerNames []string
+	for _, owner := range document.ReportingOwners {
+		relationship := owner.Relationship
+		ownerRows = append(ownerRows, InsiderReportingOwner{Year: year, Quarter: qtr, IssuerCIK: issuerCIK, IssuerName: document.Issuer.Name, IssuerTicker: document.Issuer.TradingSymbol, AccessionNumber: accessionNumber, Form: form, FilingDate: filingDate, PeriodOfReport: periodOfReport, OwnerCIK: normalizeCIK(owner.CIK), OwnerName: owner.Name, OwnerCity: owner.City, OwnerState: owner.State, IsDirector: ownershipBool(relationship.IsDirector), IsOfficer: ownershipBool(relationship.IsOfficer), IsTenPercentOwner: ownershipBool(relationship.IsTenPercentOwner), IsOther: ownershipBool(relationship.IsOther), OfficerTitle: relationship.OfficerTitle, OtherText: relationship.OtherText})
+		ownerCIKs = append(ownerCIKs, normalizeCIK(owner.CIK))
+		ownerNames = append(ownerNames, owner.Name)
+	}
+
+	var transactionRows []InsiderTransaction
+	for i, entry := range append(append([]ownershipEntry(nil), document.NonDerivativeTransactions...), document.DerivativeTransactions...) {
+		amounts, post := entry.TransactionAmounts, entry.PostTransactionAmounts
+		transactionRow := InsiderTransaction{Year: year, Quarter: qtr, IssuerCIK: issuerCIK, IssuerName: document.Issuer.Name, IssuerTicker: document.Issuer.TradingSymbol, AccessionNumber: accessionNumber, Form: form, FilingDate: filingDate, PeriodOfReport: periodOfReport, OwnerCIKs: ownerCIKs, OwnerNames: ownerNames,
+			IsDerivative: i >= len(document.NonDerivativeTransactions), SecurityTitle: entry.SecurityTitle.Value, TransactionDate: xmlDate(entry.TransactionDate.Value), DeemedExecutionDate: xmlDate(entry.DeemedExecutionDate.Value),
+			TransactionFormType: entry.Coding.FormType, TransactionCode: entry.Coding.Code, EquitySwapInvolved: ownershipBool(entry.Coding.EquitySwapInvolved), Timeliness: entry.Timeliness.Value,
+			Shares: ownershipNumber(amounts.Shares), TotalValue: ownershipNumber(amounts.TotalValue), PricePerShare: ownershipNumber(amounts.PricePerShare), AcquiredOrDisposed: amounts.AcquiredOrSold.Value,
+			SharesOwnedFollowing: ownershipNumber(post.SharesFollowing), ValueOwnedFollowing: ownershipNumber(post.ValueFollowing), DirectOrIndirect: entry.Nature.DirectOrIndirect.Value, NatureOfOwnership: entry.Nature.Nature.Value,
+			ConversionOrExercisePrice: ownershipNumber(entry.ConversionOrExercisePrice), ExerciseDate: xmlDate(entry.ExerciseDate.Value), ExpirationDate: xmlDate(entry.ExpirationDate.Value), UnderlyingSecurityTitle: entry.Underlying.Title.Value, UnderlyingShares: ownershipNumber(entry.Underlying.Shares),
+			Footnotes: document.entryFootnotes(entry)}
+		transactionRows = append(transactionRows, transactionRow)
+	}
+
+	var holdingRows []InsiderHolding
+	for i, entry := range append(append([]ownershipEntry(nil), document.NonDerivativeHoldings...), document.DerivativeHoldings...) {
+		post := entry.PostTransactionAmounts
+		holdingRow := InsiderHolding{Year: year, Quarter: qtr, IssuerCIK: issuerCIK, IssuerName: document.Issuer.Name, IssuerTicker: document.Issuer.TradingSymbol, AccessionNumber: accessionNumber, Form: form, FilingDate: filingDate, PeriodOfReport: periodOfReport, OwnerCIKs: ownerCIKs, OwnerNames: ownerNames,
+			IsDerivative: i >= len(document.NonDerivativeHoldings), SecurityTitle: entry.SecurityTitle.Value, SharesOwned: ownershipNumber(post.SharesFollowing), ValueOwned: ownershipNumber(post.ValueFollowing), DirectOrIndirect: entry.Nature.DirectOrIndirect.Value, NatureOfOwnership: entry.Nature.Nature.Value,
+			ConversionOrExercisePrice: ownershipNumber(entry.ConversionOrExercisePrice), ExerciseDate: xmlDate(entry.ExerciseDate.Value), ExpirationDate: xmlDate(entry.ExpirationDate.Value), UnderlyingSecurityTitle: entry.Underlying.Title.Value, UnderlyingShares: ownershipNumber(entry.Underlying.Shares),
+			Footnotes: document.entryFootnotes(entry)}
+		holdingRows = append(holdingRows, holdingRow)
+	}
+	return ownerRows, transactionRows, holdingRows
+}
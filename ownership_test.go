@@ -0,0 +1,155 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+//appleForm4Submission is a Form 4 complete submission text file with a sale, an indirect holding and the exercise
+//of restricted stock units, whose transaction date carries the time zone offset some filers give
+const appleForm4Submission = `
+<SEC-DOCUMENT>0001209191-20-001234.txt : 20200103
+<SEC-HEADER>0001209191-20-001234.hdr.sgml : 20200103
+ACCESSION NUMBER:		0001209191-20-001234
+CONFORMED SUBMISSION TYPE:	4
+</SEC-HEADER>
+<DOCUMENT>
+<TYPE>4
+<SEQUENCE>1
+<FILENAME>doc4.xml
+<DESCRIPTION>FORM 4 SUBMISSION
+<TEXT>
+<XML>
+<?xml version="1.0" encoding="ISO-8859-1"?>
+<ownershipDocument>
+    <schemaVersion>X0306</schemaVersion>
+    <documentType>4</documentType>
+    <periodOfReport>2020-01-02</periodOfReport>
+    <issuer><issuerCik>0000320193</issuerCik><issuerName>Apple Inc.</issuerName><issuerTradingSymbol>AAPL</issuerTradingSymbol></issuer>
+    <reportingOwner>
+        <reportingOwnerId><rptOwnerCik>0001214156</rptOwnerCik><rptOwnerName>COOK TIMOTHY D</rptOwnerName></reportingOwnerId>
+        <reportingOwnerAddress><rptOwnerCity>CUPERTINO</rptOwnerCity><rptOwnerState>CA</rptOwnerState></reportingOwnerAddress>
+        <reportingOwnerRelationship><isDirector>1</isDirector><isOfficer>true</isOfficer><officerTitle>Chief Executive Officer</officerTitle></reportingOwnerRelationship>
+    </reportingOwner>
+    <nonDerivativeTable>
+        <nonDerivativeTransaction>
+            <securityTitle><value>Common Stock</value></securityTitle>
+            <transactionDate><value>2020-01-02</value></transactionDate>
+            <transactionCoding><transactionFormType>4</transactionFormType><transactionCode>S</transactionCode><equitySwapInvolved>0</equitySwapInvolved><footnoteId id="F1"/></transactionCoding>
+            <transactionAmounts>
+                <transactionShares><value>1,000</value></transactionShares>
+                <transactionPricePerShare><value>300.35</value><footnoteId id="F2"/></transactionPricePerShare>
+                <transactionAcquiredDisposedCode><value>D</value></transactionAcquiredDisposedCode>
+            </transactionAmounts>
+            <postTransactionAmounts><sharesOwnedFollowingTransaction><value>837374</value></sharesOwnedFollowingTransaction></postTransactionAmounts>
+            <ownershipNature><directOrIndirectOwnership><value>D</value></directOrIndirectOwnership></ownershipNature>
+        </nonDerivativeTransaction>
+        <nonDerivativeHolding>
+            <securityTitle><value>Common Stock</value></securityTitle>
+            <postTransactionAmounts><sharesOwnedFollowingTransaction><value>500</value></sharesOwnedFollowingTransaction></postTransactionAmounts>
+            <ownershipNature><directOrIndirectOwnership><value>I</value></directOrIndirectOwnership><natureOfOwnership><value>By Trust</value></natureOfOwnership></ownershipNature>
+        </nonDerivativeHolding>
+    </nonDerivativeTable>
+    <derivativeTable>
+        <derivativeTransaction>
+            <securityTitle><value>Restricted Stock Unit</value></securityTitle>
+            <conversionOrExercisePrice><footnoteId id="F3"/></conversionOrExercisePrice>
+            <transactionDate><value>2020-01-02-05:00</value></transactionDate>
+            <transactionCoding><transactionFormType>4</transactionFormType><transactionCode>M</transactionCode><equitySwapInvolved>0</equitySwapInvolved></transactionCoding>
+            <transactionAmounts><transactionShares><value>2000</value></transactionShares><transactionPricePerShare><value>0</value></transactionPricePerShare><transactionAcquiredDisposedCode><value>D</value></transactionAcquiredDisposedCode></transactionAmounts>
+            <exerciseDate><footnoteId id="F4"/></exerciseDate>
+            <expirationDate><value>2021-10-01</value></expirationDate>
+            <underlyingSecurity><underlyingSecurityTitle><value>Common Stock</value></underlyingSecurityTitle><underlyingSecurityShares><value>2000</value></underlyingSecurityShares></underlyingSecurity>
+            <postTransactionAmounts><sharesOwnedFollowingTransaction><value>10000</value></sharesOwnedFollowingTransaction></postTransactionAmounts>
+            <ownershipNature><directOrIndirectOwnership><value>D</value></directOrIndirectOwnership></ownershipNature>
+        </derivativeTransaction>
+    </derivativeTable>
+    <footnotes>
+        <footnote id="F1">Sale under a
+          10b5-1 plan.</footnote>
+        <footnote id="F2">Weighted average price.</footnote>
+        <footnote id="F3">Each RSU converts into one share.</footnote>
+        <footnote id="F4">Vests in installments.</footnote>
+    </footnotes>
+</ownershipDocument>
+</XML>
+</TEXT>
+</DOCUMENT>
+</SEC-DOCUMENT>
+`
+
+func TestOwnershipRows(t *testing.T) {
+	document, err := ParseOwnershipDocument([]byte(appleForm4Submission))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerRows, transactionRows, holdingRows := OwnershipRows(document, "2020", "QTR1", "0001209191-20-001234", "4", "2020-01-03")
+
+	if len(ownerRows) != 1 {
+		t.Fatalf("got %d reporting owners, want 1", len(ownerRows))
+	}
+	owner := ownerRows[0]
+	if owner.IssuerCIK != "320193" || owner.OwnerCIK != "1214156" || owner.OwnerName != "COOK TIMOTHY D" || !owner.IsDirector || !owner.IsOfficer || owner.IsTenPercentOwner || owner.OfficerTitle != "Chief Executive Officer" {
+		t.Errorf("reporting owner = %+v", owner)
+	}
+	if owner.FilingDate != nullDate(civil.Date{Year: 2020, Month: 1, Day: 3}) || owner.PeriodOfReport != nullDate(civil.Date{Year: 2020, Month: 1, Day: 2}) {
+		t.Errorf("FilingDate = %v, PeriodOfReport = %v", owner.FilingDate, owner.PeriodOfReport)
+	}
+
+	if len(transactionRows) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactionRows))
+	}
+	sale, exercise := transactionRows[0], transactionRows[1]
+	if sale.IsDerivative || sale.TransactionCode != "S" || ratString(sale.Shares) != "1000" || ratString(sale.PricePerShare) != "6007/20" || ratString(sale.SharesOwnedFollowing) != "837374" || sale.DirectOrIndirect != "D" {
+		t.Errorf("sale = %+v", sale)
+	}
+	if want := []string{"Sale under a 10b5-1 plan.", "Weighted average price."}; !reflect.DeepEqual(sale.Footnotes, want) {
+		t.Errorf("sale Footnotes = %q, want %q", sale.Footnotes, want)
+	}
+	if !exercise.IsDerivative || exercise.TransactionCode != "M" || exercise.UnderlyingSecurityTitle != "Common Stock" || ratString(exercise.UnderlyingShares) != "2000" || exercise.ConversionOrExercisePrice != nil {
+		t.Errorf("exercise = %+v", exercise)
+	}
+	if exercise.TransactionDate != nullDate(civil.Date{Year: 2020, Month: 1, Day: 2}) {
+		t.Errorf("TransactionDate with an offset = %v, want 2020-01-02", exercise.TransactionDate)
+	}
+	if exercise.ExerciseDate.Valid || exercise.ExpirationDate != nullDate(civil.Date{Year: 2021, Month: 10, Day: 1}) {
+		t.Errorf("ExerciseDate = %v, ExpirationDate = %v", exercise.ExerciseDate, exercise.ExpirationDate)
+	}
+	if want := []string{"Each RSU converts into one share.", "Vests in installments."}; !reflect.DeepEqual(exercise.Footnotes, want) {
+		t.Errorf("exercise Footnotes = %q, want %q", exercise.Footnotes, want)
+	}
+	for _, transaction := range transactionRows {
+		if !reflect.DeepEqual(transaction.OwnerCIKs, []string{"1214156"}) {
+			t.Errorf("OwnerCIKs = %v, want the reporting owner", transaction.OwnerCIKs)
+		}
+	}
+
+	if len(holdingRows) != 1 {
+		t.Fatalf("got %d holdings, want 1", len(holdingRows))
+	}
+	holding := holdingRows[0]
+	if holding.IsDerivative || ratString(holding.SharesOwned) != "500" || holding.DirectOrIndirect != "I" || holding.NatureOfOwnership != "By Trust" || len(holding.Footnotes) != 0 {
+		t.Errorf("holding = %+v", holding)
+	}
+}
+
+func TestXMLDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bigquery.NullDate
+	}{
+		{"2020-01-02", nullDate(civil.Date{Year: 2020, Month: 1, Day: 2})},
+		{"2020-01-02-05:00", nullDate(civil.Date{Year: 2020, Month: 1, Day: 2})},
+		{" 2020-01-02Z ", nullDate(civil.Date{Year: 2020, Month: 1, Day: 2})},
+		{"", bigquery.NullDate{}},
+		{"01/02/2020", bigquery.NullDate{}},
+	}
+	for _, test := range tests {
+		if got := xmlDate(test.value); got != test.want {
+			t.Errorf("xmlDate(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
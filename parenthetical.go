@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math/big"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/anaskhan96/soup"
+)
+
+type ParentheticalItem struct {
+	Year             string
+	Quarter          string
+	CIK              string
+	AccessionNumber  string
+	FiscalYear       int
+	FiscalQuarter    string
+	Title            string
+	Date             string
+	Duration         string
+	PeriodStart      bigquery.NullDate
+	PeriodEnd        bigquery.NullDate
+	PeriodMonths     int
+	Item             string
+	Value            string
+	NumericValue     *big.Rat `bigquery:",nullable"`
+	Unit             string
+	Currency         string
+	Scale            int64
+	Dimensions       []Dimension
+	IsDefaultContext bool
+	Abstract         string
+	Tag              string
+	Definition       string
+	DataType         string
+	BalanceType      string
+	PeriodType       string
+}
+
+//ParseParenthetical parses the parenthetical disclosures of a statement (par value, shares authorized, issued and
+//outstanding, allowances). Balance sheet parentheticals have instant columns and the others have durations,
+//so the period comes from whatever headers the report has
+func ParseParenthetical(parenthetical []byte, year string, qtr string, cik string, accessionNumber string) []ParentheticalItem {
+	doc := soup.HTMLParse(string(parenthetical))
+	title, columns, rows := parseReportRows(doc)
+	scale := ParseStatementScale(title)
+
+	var parentheticalRows []ParentheticalItem
+	for _, row := range rows {
+		details := parseConceptDetails(doc, row.Tag)
+		for i, column := range columns {
+			if i >= len(row.Cells) || row.Cells[i] == "" {
+				continue
+			}
+			value := NormalizeValue(row.Cells[i], details.DataType, row.Label, scale)
+			parentheticalRow := ParentheticalItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, Title: title, Date: column.Date, Duration: column.Duration, PeriodStart: nullDate(column.Period.Start), PeriodEnd: nullDate(column.Period.End), PeriodMonths: column.Period.Months, Item: row.Label, Value: row.Cells[i], NumericValue: value.Number, Unit: value.Unit, Currency: value.Currency, Scale: value.Scale, Dimensions: row.Dimensions, IsDefaultContext: len(row.Dimensions) == 0, Abstract: row.Abstract, Tag: row.Tag, Definition: details.Definition, DataType: details.DataType, BalanceType: details.BalanceType, PeriodType: details.PeriodType}
+			parentheticalRows = append(parentheticalRows, parentheticalRow)
+		}
+	}
+	return parentheticalRows
+}
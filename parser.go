@@ -3,8 +3,11 @@ package main
 import (
 	"encoding/xml"
 	"fmt"
+	"math/big"
+	"path"
 	"strings"
 
+	"cloud.google.com/go/bigquery"
 	"github.com/anaskhan96/soup"
 )
 
@@ -38,19 +41,7 @@ type FilingSummary struct {
 	TuplesReported    string   `xml:"TuplesReported"`
 	UnitCount         string   `xml:"UnitCount"`
 	MyReports         struct {
-		Report []struct {
-			Instance           string `xml:"instance,attr"`
-			IsDefault          string `xml:"IsDefault"`
-			HasEmbeddedReports string `xml:"HasEmbeddedReports"`
-			HtmlFileName       string `xml:"HtmlFileName"`
-			LongName           string `xml:"LongName"`
-			ReportType         string `xml:"ReportType"`
-			Role               string `xml:"Role"`
-			ShortName          string `xml:"ShortName"`
-			MenuCategory       string `xml:"MenuCategory"`
-			Position           string `xml:"Position"`
-			ParentRole         string `xml:"ParentRole"`
-		} `xml:"Report"`
+		Report []FilingSummaryReport `xml:"Report"`
 	} `xml:"MyReports"`
 	InputFiles struct {
 		File []string `xml:"File"`
@@ -63,231 +54,151 @@ type FilingSummary struct {
 	HasCalculationLinkbase  string `xml:"HasCalculationLinkbase"`
 }
 
+//Defining struct for each report (R page) listed in the filing summary
+type FilingSummaryReport struct {
+	Instance           string `xml:"instance,attr"`
+	IsDefault          string `xml:"IsDefault"`
+	HasEmbeddedReports string `xml:"HasEmbeddedReports"`
+	HtmlFileName       string `xml:"HtmlFileName"`
+	LongName           string `xml:"LongName"`
+	ReportType         string `xml:"ReportType"`
+	Role               string `xml:"Role"`
+	ShortName          string `xml:"ShortName"`
+	MenuCategory       string `xml:"MenuCategory"`
+	Position           string `xml:"Position"`
+	ParentRole         string `xml:"ParentRole"`
+}
+
 type BalanceSheetItem struct {
-	Year        string
-	Quarter     string
-	CIK         string
-	Title       string
-	Date        string
-	Item        string
-	Value       string
-	Axis        string
-	Abstract    string
-	Tag         string
-	Definition  string
-	DataType    string
-	BalanceType string
-	PeriodType  string
-	Footnote    string
+	Year             string
+	Quarter          string
+	CIK              string
+	AccessionNumber  string
+	FiscalYear       int
+	FiscalQuarter    string
+	Title            string
+	Date             string
+	PeriodEnd        bigquery.NullDate
+	Item             string
+	Value            string
+	NumericValue     *big.Rat `bigquery:",nullable"`
+	Unit             string
+	Currency         string
+	Scale            int64
+	Dimensions       []Dimension
+	IsDefaultContext bool
+	Abstract         string
+	Tag              string
+	Definition       string
+	DataType         string
+	BalanceType      string
+	PeriodType       string
+	Footnote         string
 }
 
 type IncomeOrCashFlowStatementItem struct {
-	Year        string
-	Quarter     string
-	CIK         string
-	Title       string
-	Date        string
-	Item        string
-	Value       string
-	Duration    string
-	Axis        string
-	Abstract    string
-	Tag         string
-	Definition  string
-	DataType    string
-	BalanceType string
-	PeriodType  string
-	Footnote    string
+	Year             string
+	Quarter          string
+	CIK              string
+	AccessionNumber  string
+	FiscalYear       int
+	FiscalQuarter    string
+	Title            string
+	Date             string
+	Item             string
+	Value            string
+	NumericValue     *big.Rat `bigquery:",nullable"`
+	Unit             string
+	Currency         string
+	Scale            int64
+	Duration         string
+	PeriodStart      bigquery.NullDate
+	PeriodEnd        bigquery.NullDate
+	PeriodMonths     int
+	Dimensions       []Dimension
+	IsDefaultContext bool
+	Abstract         string
+	Tag              string
+	Definition       string
+	DataType         string
+	BalanceType      string
+	PeriodType       string
+	Footnote         string
 }
 
-func ParseFilingSummary(filingSummaryObject FilingSummary, filingDirectoryIndexURL string) (string, string, string) {
-	balanceSheetNames := []string{"balance sheet", "statements of financial condition", "statements of condition"}
-	incomeStatementNames := []string{"statements of income", "statements of operation", "statement of income", "statements of earnings", "statements of comprehensive loss", "statement of operations and comprehensive loss"}
-	cashFlowStatementNames := []string{"statements of cash flow", "statement of cash flow"}
-	balanceSheetFound := false
-	incomeStatementFound := false
-	cashFlowStatementFound := false
-	var balanceSheetURL string
-	var incomeStatementURL string
-	var cashFlowStatementURL string
-	for _, report := range filingSummaryObject.MyReports.Report {
-		for _, name := range balanceSheetNames {
-			if !balanceSheetFound && strings.Contains(strings.ToLower(report.LongName), name) && !strings.Contains(strings.ToLower(report.LongName), "parenthetical") {
-				balanceSheetFound = true
-				balanceSheetURL = filingDirectoryIndexURL + "/" + report.HtmlFileName
-				break
-			}
-		}
-		for _, name := range incomeStatementNames {
-			if !incomeStatementFound && strings.Contains(strings.ToLower(report.LongName), name) {
-				incomeStatementFound = true
-				incomeStatementURL = filingDirectoryIndexURL + "/" + report.HtmlFileName
-				break
-			}
-		}
-		for _, name := range cashFlowStatementNames {
-			if !cashFlowStatementFound && strings.Contains(strings.ToLower(report.LongName), name) {
-				cashFlowStatementFound = true
-				cashFlowStatementURL = filingDirectoryIndexURL + "/" + report.HtmlFileName
-				break
-			}
-		}
-		if balanceSheetFound && incomeStatementFound && cashFlowStatementFound {
-			break
-		}
-	}
-	return balanceSheetURL, incomeStatementURL, cashFlowStatementURL
+//AccessionNumber returns the accession number (0000320193-20-000010) of a filing from its location in the index (edgar/data/320193/0000320193-20-000010.txt)
+func AccessionNumber(filingLoc string) string {
+	return strings.TrimSuffix(path.Base(filingLoc), ".txt")
 }
 
-func ParseBalanceSheet(balanceSheet []byte, year string, qtr string, cik string) []BalanceSheetItem {
+//ParseFilingSummary builds the catalog of every report in a filing summary, with its statements classified by the rules
+func ParseFilingSummary(filingSummaryObject FilingSummary, filingDirectoryIndexURL string, rules StatementRules) ReportCatalog {
+	return NewReportCatalog(filingSummaryObject, filingDirectoryIndexURL, rules)
+}
 
-	//turn balance sheet into soup object for parsing
+//ParseBalanceSheet reads the line items of a balance sheet R page, one row per line item and date column. Rows,
+//concept details and footnotes are read with the bounds checked report helpers, so an unusual R page loses
+//the details it lacks rather than stopping the load
+func ParseBalanceSheet(balanceSheet []byte, year string, qtr string, cik string, accessionNumber string) []BalanceSheetItem {
 	doc := soup.HTMLParse(string(balanceSheet))
+	title, columns, rows := parseReportRows(doc)
+	footnotes := parseFootnotes(doc)
+	scale := ParseStatementScale(title)
 
-	//variables to store data and control flow
-	columnHeadersFound := false
-	var axes, abstracts, tags, definitions, dataTypes, balanceTypes, periodTypes, items, dates, footnotes []string
-	var values [][]string
-	axis, abstract, title := "", "", ""
-	rows := doc.Find("table").FindAll("tr")
-
-	//iterating over rows in balance sheet
-RowLoopBS:
-	for _, row := range rows {
-		if !columnHeadersFound {
-			for _, columnHeader := range row.FindAll("th") {
-				if columnHeader.Attrs()["class"] == "tl" {
-					title = columnHeader.FullText()
-				}
-				if columnHeader.Attrs()["class"] == "th" {
-					dates = append(dates, columnHeader.FullText())
-				}
-			}
-			columnHeadersFound = true
-			values = make([][]string, len(dates))
-			//fmt.Println(title, dates, values)
-			continue
-		}
-		index := 0
-		//iterating over cells in row
-		for _, value := range row.FindAll("td") {
-			//each td tag has a tag that corresponds with some type of data that is consistent across all xbrl statements on the sec from what I've seen
-			switch class := value.Attrs()["class"]; class {
-			case "pl ", "pl custom":
-				xbrlTag := strings.Replace(strings.Replace(value.Find("a").Attrs()["onclick"], "top.Show.showAR( this, '", "", 1), "', window );", "", 1)
-				if strings.Contains(xbrlTag, "Axis") {
-					axis = xbrlTag
-					continue RowLoopBS
-				}
-				if strings.Contains(xbrlTag, "Abstract") {
-					abstract = xbrlTag
-					continue RowLoopBS
-				}
-				items = append(items, value.FullText())
-				tags = append(tags, xbrlTag)
-				axes = append(axes, axis)
-				abstracts = append(abstracts, abstract)
-			case "nump", "num", "text":
-				values[index] = append(values[index], value.FullText())
-				index = index + 1
-			case "th":
-				footnotes = append(footnotes, value.FullText())
+	details := make(map[string]conceptDetails)
+	var balanceSheetRows []BalanceSheetItem
+	for i, column := range columns {
+		for _, row := range rows {
+			if i >= len(row.Cells) {
+				continue
 			}
-		}
-	}
-	// fmt.Println(values)
-	// fmt.Println(tags)
-	// fmt.Println(items)
-	// fmt.Println(tags)
-	// fmt.Println(axes)
-	// fmt.Println(abstracts)
-
-	for _, tag := range tags {
-		div := doc.Find("table", "id", tag).Find("tr").FindNextElementSibling().Find("div", "class", "body")
-		definitions = append(definitions, div.Find("div").Find("p").Text())
-		dataTypes = append(dataTypes, div.FindAll("div")[2].FindAll("tr")[2].FindAll("td")[1].Text())
-		balanceTypes = append(balanceTypes, div.FindAll("div")[2].FindAll("tr")[3].FindAll("td")[1].Text())
-		periodTypes = append(periodTypes, div.FindAll("div")[2].FindAll("tr")[4].FindAll("td")[1].Text())
-	}
-
-	foundFootnotes := false
-	//checking to see if footnotes exist
-	footnotesTable := doc.Find("table", "class", "outerFootnotes").Error
-	if footnotesTable == nil {
-		fmt.Println("Footnotes found")
-		foundFootnotes = true
-	} else {
-		fmt.Println("Footnotes not found: ", footnotesTable)
-	}
-
-	//need to get footnotes somehow
-	if foundFootnotes {
-		for index, footnoteNum := range footnotes {
-			for _, footnote := range doc.Find("table", "class", "outerFootnotes").FindAll("tr") {
-				if footnote.Find("td").FullText() == footnoteNum {
-					// fmt.Println(footnote.Find("td").FullText())
-					// fmt.Println(footnote.Find("td").FindNextElementSibling().FullText())
-					footnotes[index] = footnote.Find("td").FindNextElementSibling().FullText()
-				}
+			concept, ok := details[row.Tag]
+			if !ok {
+				concept = parseConceptDetails(doc, row.Tag)
+				details[row.Tag] = concept
 			}
-		}
-	} else {
-		footnotes = make([]string, len(items))
-	}
-	fmt.Println(footnotes)
-	// fmt.Println(len(dates), len(items), len(values), len(values[1]), len(axes), len(abstracts), len(tags), len(definitions), len(dataTypes), len(balanceTypes), len(periodTypes), len(footnotes))
-	var balanceSheetRows []BalanceSheetItem
-	for ii := range dates {
-		for i := range items {
-			balanceSheetRow := BalanceSheetItem{Year: year, Quarter: qtr, CIK: cik, Title: title, Date: dates[ii], Item: items[i], Value: values[ii][i], Axis: axes[i], Abstract: abstracts[i], Tag: tags[i], Definition: definitions[i], DataType: dataTypes[i], BalanceType: balanceTypes[i], PeriodType: periodTypes[i], Footnote: footnotes[i]}
+			value := NormalizeValue(row.Cells[i], concept.DataType, row.Label, scale)
+			balanceSheetRow := BalanceSheetItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, Title: title, Date: column.Date, PeriodEnd: nullDate(column.Period.End), Item: row.Label, Value: row.Cells[i], NumericValue: value.Number, Unit: value.Unit, Currency: value.Currency, Scale: value.Scale, Dimensions: row.Dimensions, IsDefaultContext: len(row.Dimensions) == 0, Abstract: row.Abstract, Tag: row.Tag, Definition: concept.Definition, DataType: concept.DataType, BalanceType: concept.BalanceType, PeriodType: concept.PeriodType, Footnote: footnotes[row.Footnote]}
 			balanceSheetRows = append(balanceSheetRows, balanceSheetRow)
 		}
 	}
 	return balanceSheetRows
 }
 
-func ParseIncomeOrCashFlowStatement(incomeOrCashFlowStatement []byte, year string, qtr string, cik string) []IncomeOrCashFlowStatementItem {
+func ParseIncomeOrCashFlowStatement(incomeOrCashFlowStatement []byte, year string, qtr string, cik string, accessionNumber string) []IncomeOrCashFlowStatementItem {
 
 	//soup object to traverse html document
 	doc := soup.HTMLParse(string(incomeOrCashFlowStatement))
 
 	//variables and arrays to store data and control flow
 	columnHeadersFound, multipleColumnFootnotesExist := false, false
-	datesFound := false
-	var axes, abstracts, tags, definitions, dataTypes, balanceTypes, periodTypes, items, footnotes []string
+	var abstracts, tags, definitions, dataTypes, balanceTypes, periodTypes, items, footnotes []string
 	var values [][]string
 	var multipleColumnFootnotes [][]string
-	var dates []string
+	var headerRows []soup.Root
+	var columns []StatementColumn
 	var details []soup.Root
-	axis, abstract, title, duration := "", "", "", ""
+	var dimensions [][]Dimension
+	var dims dimensionContext
+	abstract, title := "", ""
 	rows := doc.Find("table").FindAll("tr")
 
 	//iterate over all financial statement rows
 RowloopICFS:
 	for _, row := range rows {
 
-		//Getting title and time period of income/cash flow statement
+		//Getting title and time periods of income/cash flow statement. Duration groups like "3 Months Ended"
+		//and "9 Months Ended" span their date columns by colspan, so the header rows are parsed together
 		if !columnHeadersFound {
-			for _, columnHeader := range row.FindAll("th") {
-				if columnHeader.Attrs()["class"] == "tl" {
-					title = columnHeader.FullText()
-				}
-				if columnHeader.Attrs()["class"] == "th" {
-					duration = columnHeader.FullText()
-				}
+			if len(row.FindAll("td")) == 0 {
+				headerRows = append(headerRows, row)
+				continue
 			}
+			title, columns = parseColumnHeaders(headerRows)
+			values = make([][]string, len(columns))
+			multipleColumnFootnotes = make([][]string, len(columns))
 			columnHeadersFound = true
-			continue
-		}
-		if !datesFound {
-			for _, date := range row.FindAll("th") {
-				dates = append(dates, date.FullText())
-			}
-			values = make([][]string, len(dates))
-			multipleColumnFootnotes = make([][]string, len(dates))
-			datesFound = true
-			fmt.Println(title, dates, values)
-			continue
+			fmt.Println(title, columns, values)
 		}
 		footnoteColumnIndex := 0
 		index := 0
@@ -296,9 +207,21 @@ RowloopICFS:
 			//each td tag has a tag that corresponds with some type of data that is consistent across all xbrl statements on the sec from what I've seen
 			switch class := value.Attrs()["class"]; class {
 			case "pl ", "pl custom":
-				xbrlTag := strings.Replace(strings.Replace(value.Find("a").Attrs()["onclick"], "top.Show.showAR( this, '", "", 1), "', window );", "", 1)
+				link := value.Find("a")
+				if link.Error != nil {
+					//typed dimension members are plain text under their axis rather than a link to a concept
+					if dims.expectingMember() {
+						dims.member(strings.TrimSpace(value.FullText()), value.FullText(), true)
+					}
+					continue RowloopICFS
+				}
+				xbrlTag := strings.Replace(strings.Replace(link.Attrs()["onclick"], "top.Show.showAR( this, '", "", 1), "', window );", "", 1)
 				if strings.Contains(xbrlTag, "Axis") {
-					axis = xbrlTag
+					dims.axis(xbrlTag)
+					continue RowloopICFS
+				}
+				if isMemberTag(xbrlTag) {
+					dims.member(xbrlTag, value.FullText(), false)
 					continue RowloopICFS
 				}
 				if strings.Contains(xbrlTag, "Abstract") {
@@ -307,7 +230,7 @@ RowloopICFS:
 				}
 				items = append(items, value.FullText())
 				tags = append(tags, xbrlTag)
-				axes = append(axes, axis)
+				dimensions = append(dimensions, dims.lineItem())
 				abstracts = append(abstracts, abstract)
 			case "nump", "num", "text":
 				values[index] = append(values[index], value.FullText())
@@ -378,20 +301,23 @@ RowloopICFS:
 	} else {
 		footnotes = make([]string, len(items))
 	}
-	fmt.Println(len(dates), len(items), len(values), len(values[1]), len(axes), len(abstracts), len(tags), len(definitions), len(dataTypes), len(balanceTypes), len(periodTypes), len(footnotes))
+	fmt.Println(len(columns), len(items), len(values), len(values[1]), len(dimensions), len(abstracts), len(tags), len(definitions), len(dataTypes), len(balanceTypes), len(periodTypes), len(footnotes))
+	scale := ParseStatementScale(title)
 	var incomeOrCashFlowStatementRows []IncomeOrCashFlowStatementItem
 	if multipleColumnFootnotesExist {
 		fmt.Println("gotta handle this shit")
-		for ii := range dates {
+		for ii := range columns {
 			for i := range items {
-				incomeOrCashFlowStatementRow := IncomeOrCashFlowStatementItem{Year: year, Quarter: qtr, CIK: cik, Title: title, Date: dates[ii], Item: items[i], Value: values[ii][i], Duration: duration, Axis: axes[i], Abstract: abstracts[i], Tag: tags[i], Definition: definitions[i], DataType: dataTypes[i], BalanceType: balanceTypes[i], PeriodType: periodTypes[i], Footnote: multipleColumnFootnotes[ii][i]}
+				value := NormalizeValue(values[ii][i], dataTypes[i], items[i], scale)
+				incomeOrCashFlowStatementRow := IncomeOrCashFlowStatementItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, Title: title, Date: columns[ii].Date, PeriodEnd: nullDate(columns[ii].Period.End), Item: items[i], Value: values[ii][i], NumericValue: value.Number, Unit: value.Unit, Currency: value.Currency, Scale: value.Scale, Duration: columns[ii].Duration, PeriodStart: nullDate(columns[ii].Period.Start), PeriodMonths: columns[ii].Period.Months, Dimensions: dimensions[i], IsDefaultContext: len(dimensions[i]) == 0, Abstract: abstracts[i], Tag: tags[i], Definition: definitions[i], DataType: dataTypes[i], BalanceType: balanceTypes[i], PeriodType: periodTypes[i], Footnote: multipleColumnFootnotes[ii][i]}
 				incomeOrCashFlowStatementRows = append(incomeOrCashFlowStatementRows, incomeOrCashFlowStatementRow)
 			}
 		}
 	} else {
-		for ii := range dates {
+		for ii := range columns {
 			for i := range items {
-				incomeOrCashFlowStatementRow := IncomeOrCashFlowStatementItem{Year: year, Quarter: qtr, CIK: cik, Title: title, Date: dates[ii], Item: items[i], Value: values[ii][i], Duration: duration, Axis: axes[i], Abstract: abstracts[i], Tag: tags[i], Definition: definitions[i], DataType: dataTypes[i], BalanceType: balanceTypes[i], PeriodType: periodTypes[i], Footnote: footnotes[i]}
+				value := NormalizeValue(values[ii][i], dataTypes[i], items[i], scale)
+				incomeOrCashFlowStatementRow := IncomeOrCashFlowStatementItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, Title: title, Date: columns[ii].Date, PeriodEnd: nullDate(columns[ii].Period.End), Item: items[i], Value: values[ii][i], NumericValue: value.Number, Unit: value.Unit, Currency: value.Currency, Scale: value.Scale, Duration: columns[ii].Duration, PeriodStart: nullDate(columns[ii].Period.Start), PeriodMonths: columns[ii].Period.Months, Dimensions: dimensions[i], IsDefaultContext: len(dimensions[i]) == 0, Abstract: abstracts[i], Tag: tags[i], Definition: definitions[i], DataType: dataTypes[i], BalanceType: balanceTypes[i], PeriodType: periodTypes[i], Footnote: footnotes[i]}
 				incomeOrCashFlowStatementRows = append(incomeOrCashFlowStatementRows, incomeOrCashFlowStatementRow)
 			}
 		}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/anaskhan96/soup"
+)
+
+//StatementColumn is one value column of a statement along with the header text and period it was reported for
+type StatementColumn struct {
+	Duration string
+	Date     string
+	Period   Period
+}
+
+//Period is the span a column covers. Instants (balance sheet dates) have a zero Start and zero Months
+type Period struct {
+	Start  civil.Date
+	End    civil.Date
+	Months int
+	Weeks  int
+}
+
+var (
+	durationPattern   = regexp.MustCompile(`(\d+) (Months|Weeks) Ended`)
+	periodDatePattern = regexp.MustCompile(`([A-Z][a-z]{2,8})\.? (\d{1,2}), (\d{4})`)
+)
+
+//Label is the full header text of the column, for statements whose columns are not periods
+func (c StatementColumn) Label() string {
+	return strings.TrimSpace(c.Duration + " " + c.Date)
+}
+
+//IsInstant reports whether the period is a point in time rather than a duration
+func (p Period) IsInstant() bool {
+	return p.Months == 0 && p.Weeks == 0
+}
+
+//parsePeriodDate parses header dates like "Mar. 31, 2020", "Sept. 30, 2020" or "June 30, 2020"
+func parsePeriodDate(text string) (civil.Date, bool) {
+	match := periodDatePattern.FindStringSubmatch(text)
+	if match == nil {
+		return civil.Date{}, false
+	}
+	month := match[1]
+	if len(month) > 3 {
+		month = month[:3]
+	}
+	date, err := time.Parse("Jan 2 2006", month+" "+match[2]+" "+match[3])
+	if err != nil {
+		return civil.Date{}, false
+	}
+	return civil.DateOf(date), true
+}
+
+func lastDayOfMonth(year int, month time.Month) civil.Date {
+	return civil.DateOf(time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC))
+}
+
+//nearestWeekday returns the day with the given weekday closest to date, as 52/53 week fiscal years end on
+//the same weekday nearest to a month end
+func nearestWeekday(date civil.Date, weekday time.Weekday) civil.Date {
+	offset := int(weekday - date.In(time.UTC).Weekday())
+	if offset > 3 {
+		offset -= 7
+	}
+	if offset < -3 {
+		offset += 7
+	}
+	return date.AddDays(offset)
+}
+
+//lastWeekday returns the last day of a month falling on the given weekday, as some 52/53 week fiscal years end on
+//the last Saturday of a month rather than the Saturday nearest to its end
+func lastWeekday(year int, month time.Month, weekday time.Weekday) civil.Date {
+	last := lastDayOfMonth(year, month)
+	return last.AddDays(-int((last.In(time.UTC).Weekday() - weekday + 7) % 7))
+}
+
+//periodStart works back from the end of a duration to its first day
+func periodStart(end civil.Date, months int, weeks int) civil.Date {
+	if weeks > 0 {
+		return end.AddDays(1 - 7*weeks)
+	}
+	if end == lastDayOfMonth(end.Year, end.Month) {
+		return civil.DateOf(time.Date(end.Year, end.Month+1-time.Month(months), 1, 0, 0, 0, 0, time.UTC))
+	}
+	//filers on a 52/53 week calendar label their periods in months but end them on a weekday close to the
+	//month end, e.g. "12 Months Ended Feb. 1, 2020", so the prior period ended on the same weekday a year earlier
+	nominalMonth := civil.Date{Year: end.Year, Month: end.Month, Day: 1}
+	if end.Day <= 7 {
+		nominalMonth = civil.DateOf(nominalMonth.In(time.UTC).AddDate(0, -1, 0))
+	}
+	if end.Day <= 7 || end.Day >= lastDayOfMonth(end.Year, end.Month).Day-6 {
+		weekday := end.In(time.UTC).Weekday()
+		priorMonth := civil.DateOf(nominalMonth.In(time.UTC).AddDate(0, -months, 0))
+		priorEnd := nearestWeekday(lastDayOfMonth(priorMonth.Year, priorMonth.Month), weekday)
+		//a period ending on the last weekday of the month, which isn't the one nearest the month end, follows the
+		//last weekday convention (Apple's "last Saturday of September")
+		if end == lastWeekday(end.Year, end.Month, weekday) && end != nearestWeekday(lastDayOfMonth(end.Year, end.Month), weekday) {
+			priorEnd = lastWeekday(priorMonth.Year, priorMonth.Month, weekday)
+		}
+		return priorEnd.AddDays(1)
+	}
+	return civil.DateOf(end.In(time.UTC).AddDate(0, -months, 1))
+}
+
+//ParsePeriod turns a column's duration header ("3 Months Ended", "13 Weeks Ended" or empty for instants)
+//and date header into a concrete period
+func ParsePeriod(duration string, date string) (Period, bool) {
+	end, ok := parsePeriodDate(date)
+	if !ok {
+		return Period{}, false
+	}
+	period := Period{End: end}
+	match := durationPattern.FindStringSubmatch(duration + " " + date)
+	if match == nil {
+		return period, true
+	}
+	length, _ := strconv.Atoi(match[1])
+	if length == 0 {
+		return period, true
+	}
+	if match[2] == "Weeks" {
+		period.Weeks = length
+		period.Months = int(math.Round(float64(length) * 12 / 52))
+	} else {
+		period.Months = length
+	}
+	period.Start = periodStart(end, period.Months, period.Weeks)
+	return period, true
+}
+
+func colspan(cell soup.Root) int {
+	span, err := strconv.Atoi(cell.Attrs()["colspan"])
+	if err != nil || span < 1 {
+		return 1
+	}
+	return span
+}
+
+//parseColumnHeaders reads the header rows of a statement table. The title sits in the "tl" cell, the last
+//header row holds a date per value column and any rows above it hold duration groups spanning columns by colspan
+func parseColumnHeaders(headerRows []soup.Root) (string, []StatementColumn) {
+	title := ""
+	var groups [][]string
+	var columns []StatementColumn
+	for rowIndex, row := range headerRows {
+		var labels []string
+		for _, columnHeader := range row.FindAll("th") {
+			if columnHeader.Attrs()["class"] == "tl" {
+				title = columnHeader.FullText()
+				continue
+			}
+			text := strings.TrimSpace(columnHeader.FullText())
+			for i := 0; i < colspan(columnHeader); i++ {
+				labels = append(labels, text)
+			}
+		}
+		if rowIndex < len(headerRows)-1 {
+			groups = append(groups, labels)
+			continue
+		}
+		for _, date := range labels {
+			columns = append(columns, StatementColumn{Date: date})
+		}
+	}
+	for i := range columns {
+		var durations []string
+		for _, group := range groups {
+			if i < len(group) && group[i] != "" {
+				durations = append(durations, group[i])
+			}
+		}
+		columns[i].Duration = strings.Join(durations, " ")
+		columns[i].Period, _ = ParsePeriod(columns[i].Duration, columns[i].Date)
+	}
+	return title, columns
+}
+
+//nullDate converts a period bound into a BigQuery DATE that is NULL when the bound is unknown
+func nullDate(date civil.Date) bigquery.NullDate {
+	return bigquery.NullDate{Date: date, Valid: date.IsValid()}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+func TestParsePeriod(t *testing.T) {
+	date := func(year int, month time.Month, day int) civil.Date {
+		return civil.Date{Year: year, Month: month, Day: day}
+	}
+	tests := []struct {
+		name     string
+		duration string
+		date     string
+		want     Period
+	}{
+		{"balance sheet instant", "", "Sep. 28, 2019", Period{End: date(2019, time.September, 28)}},
+		{"calendar quarter", "3 Months Ended", "Mar. 31, 2020", Period{Start: date(2020, time.January, 1), End: date(2020, time.March, 31), Months: 3}},
+		{"calendar year", "12 Months Ended", "Dec. 31, 2019", Period{Start: date(2019, time.January, 1), End: date(2019, time.December, 31), Months: 12}},
+		{"sept abbreviation", "9 Months Ended", "Sept. 30, 2020", Period{Start: date(2020, time.January, 1), End: date(2020, time.September, 30), Months: 9}},
+		{"full month name", "6 Months Ended", "June 30, 2020", Period{Start: date(2020, time.January, 1), End: date(2020, time.June, 30), Months: 6}},
+		{"mid month fiscal quarter", "3 Months Ended", "Mar. 15, 2020", Period{Start: date(2019, time.December, 16), End: date(2020, time.March, 15), Months: 3}},
+		{"walmart year ending on the month end", "12 Months Ended", "Jan. 31, 2020", Period{Start: date(2019, time.February, 1), End: date(2020, time.January, 31), Months: 12}},
+		{"home depot sunday nearest january end", "12 Months Ended", "Feb. 02, 2020", Period{Start: date(2019, time.February, 4), End: date(2020, time.February, 2), Months: 12}},
+		{"home depot 53 week year", "12 Months Ended", "Feb. 03, 2019", Period{Start: date(2018, time.January, 29), End: date(2019, time.February, 3), Months: 12}},
+		{"apple saturday nearest september end", "12 Months Ended", "Sep. 28, 2019", Period{Start: date(2018, time.September, 30), End: date(2019, time.September, 28), Months: 12}},
+		{"apple last saturday of september", "12 Months Ended", "Sep. 24, 2022", Period{Start: date(2021, time.September, 26), End: date(2022, time.September, 24), Months: 12}},
+		{"apple last saturday of december quarter", "3 Months Ended", "Dec. 25, 2021", Period{Start: date(2021, time.September, 26), End: date(2021, time.December, 25), Months: 3}},
+		{"weeks", "13 Weeks Ended", "May 02, 2020", Period{Start: date(2020, time.February, 2), End: date(2020, time.May, 2), Months: 3, Weeks: 13}},
+		{"53 weeks", "53 Weeks Ended", "Feb. 03, 2018", Period{Start: date(2017, time.January, 29), End: date(2018, time.February, 3), Months: 12, Weeks: 53}},
+		{"duration in the date header", "", "3 Months Ended Jun. 30, 2020", Period{Start: date(2020, time.April, 1), End: date(2020, time.June, 30), Months: 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := ParsePeriod(test.duration, test.date)
+			if !ok {
+				t.Fatalf("ParsePeriod(%q, %q) not parsed", test.duration, test.date)
+			}
+			if got != test.want {
+				t.Errorf("ParsePeriod(%q, %q) = %+v, want %+v", test.duration, test.date, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePeriodUnparsed(t *testing.T) {
+	for _, date := range []string{"", "USD ($)", "Document and Entity Information"} {
+		if _, ok := ParsePeriod("12 Months Ended", date); ok {
+			t.Errorf("ParsePeriod(%q) parsed, want not ok", date)
+		}
+	}
+}
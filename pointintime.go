@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//PointInTimeQuery turns the reported_values history into validity intervals. A figure is known from the acceptance
+//of the first filing reporting it until a filing reporting a different value is accepted (KnownUntil is null while
+//the value is current), later filings repeating the same value don't start a new interval. Only filings accepted
+//before asOf are considered when it is set
+func PointInTimeQuery(projectName string, asOf time.Time) string {
+	filter := ""
+	if !asOf.IsZero() {
+		filter = " AND AcceptanceDateTime < @asOf"
+	}
+	return fmt.Sprintf("WITH reports AS (\n"+
+		"SELECT * EXCEPT(RowInFiling) FROM (\n"+
+		"SELECT CIK, Concept, DimensionKey, Dimensions, Unit, Currency, PeriodStart, PeriodEnd, PeriodMonths, FiscalYear, FiscalQuarter, Value, NumericValue, AccessionNumber, Form, FilingDate, AcceptanceDateTime,\n"+
+		"ROW_NUMBER() OVER (PARTITION BY CIK, Concept, DimensionKey, Unit, Currency, PeriodStart, PeriodEnd, AccessionNumber ORDER BY Statement) AS RowInFiling\n"+
+		"FROM `%[1]s.SEC.reported_values` WHERE AcceptanceDateTime IS NOT NULL%[3]s\n"+
+		") WHERE RowInFiling = 1\n"+
+		"), changes AS (\n"+
+		"SELECT *, ROW_NUMBER() OVER figure AS ReportNumber, LAG(NumericValue) OVER figure AS PreviousNumericValue FROM reports WINDOW figure AS (%[2]s)\n"+
+		")\n"+
+		"SELECT * EXCEPT(ReportNumber, PreviousNumericValue), AcceptanceDateTime AS KnownFrom, LEAD(AcceptanceDateTime) OVER figure AS KnownUntil\n"+
+		"FROM changes WHERE ReportNumber = 1 OR NumericValue IS DISTINCT FROM PreviousNumericValue\n"+
+		"WINDOW figure AS (%[2]s)", projectName, reportedValuesWindow, filter)
+}
+
+//pointInTimeCommand materializes the point_in_time table from reported_values and optionally exports it as Parquet.
+//The values known at any time t are the rows with KnownFrom <= t and KnownUntil null or after t
+func pointInTimeCommand(args []string) {
+	flags := flag.NewFlagSet("point-in-time", flag.ExitOnError)
+	asOfFlag := flags.String("as-of", "", "only use filings accepted before this time (RFC 3339 or YYYY-MM-DD)")
+	parquet := flags.String("parquet", "", "also export the table as Parquet files to this Cloud Storage URI, e.g. gs://bucket/point_in_time/*.parquet")
+	flags.Parse(args)
+
+	var asOf time.Time
+	if *asOfFlag != "" {
+		var err error
+		if asOf, err = time.Parse(time.RFC3339, *asOfFlag); err != nil {
+			if asOf, err = time.ParseInLocation("2006-01-02", *asOfFlag, edgarLocation); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	fmt.Println("Please enter in your Google Cloud project name: ")
+	var projectName string
+	fmt.Scanln(&projectName)
+	ctx := context.Background()
+	bq, err := bigquery.NewClient(ctx, projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bq.Close()
+
+	pointInTimeTable := bq.Dataset("SEC").Table("point_in_time")
+	q := bq.Query(PointInTimeQuery(projectName, asOf))
+	if !asOf.IsZero() {
+		q.Parameters = []bigquery.QueryParameter{{Name: "asOf", Value: asOf}}
+	}
+	q.Dst = pointInTimeTable
+	q.WriteDisposition = bigquery.WriteTruncate
+	job, err := q.Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := status.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Point in time table materialized")
+
+	if *parquet != "" {
+		gcsRef := bigquery.NewGCSReference(*parquet)
+		gcsRef.DestinationFormat = bigquery.Parquet
+		job, err := pointInTimeTable.ExtractorTo(gcsRef).Run(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		status, err := job.Wait(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := status.Err(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Point in time table exported to", *parquet)
+	}
+}
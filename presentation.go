@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+//NegatedLabels holds the concepts a filing presents with a negated label (negatedLabel, negatedTotalLabel...) by
+//extended link role, then concept. R pages show those values with the opposite sign of the XBRL fact
+type NegatedLabels map[string]map[string]bool
+
+type presentationLinkbaseXML struct {
+	PresentationLinks []struct {
+		Role     string `xml:"role,attr"`
+		Locators []struct {
+			Href  string `xml:"href,attr"`
+			Label string `xml:"label,attr"`
+		} `xml:"loc"`
+		Arcs []struct {
+			To             string `xml:"to,attr"`
+			PreferredLabel string `xml:"preferredLabel,attr"`
+		} `xml:"presentationArc"`
+	} `xml:"presentationLink"`
+}
+
+//PresentationLinkbaseFile returns the presentation linkbase (e.g. aapl-20191228_pre.xml) among a filing's input files
+func PresentationLinkbaseFile(filingSummaryObject FilingSummary) (string, bool) {
+	for _, file := range filingSummaryObject.InputFiles.File {
+		file = strings.TrimSpace(file)
+		if strings.HasSuffix(strings.ToLower(file), "_pre.xml") {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+//ParsePresentationLinkbase reads the presentation arcs of a linkbase whose preferred label is one of the negated
+//label roles, turning locator hrefs into QNames like ParseCalculationLinkbase
+func ParsePresentationLinkbase(linkbase []byte) NegatedLabels {
+	var parsed presentationLinkbaseXML
+	negated := make(NegatedLabels)
+	if err := xml.Unmarshal(linkbase, &parsed); err != nil {
+		return negated
+	}
+	for _, link := range parsed.PresentationLinks {
+		concepts := make(map[string]string)
+		for _, locator := range link.Locators {
+			concepts[locator.Label] = strings.Replace(locator.Href[strings.Index(locator.Href, "#")+1:], "_", ":", 1)
+		}
+		for _, arc := range link.Arcs {
+			if !strings.Contains(strings.ToLower(arc.PreferredLabel), "/negated") {
+				continue
+			}
+			if negated[link.Role] == nil {
+				negated[link.Role] = make(map[string]bool)
+			}
+			negated[link.Role][concepts[arc.To]] = true
+		}
+	}
+	return negated
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/Sampada-DeFi/Sampada-Research-ETL/metrics"
+	"google.golang.org/api/iterator"
+)
+
+//MetricItem is a ratio or amount computed for a company's fiscal period, tagged with the version of its formula
+type MetricItem struct {
+	CIK            string
+	FiscalYear     int
+	FiscalQuarter  string
+	PeriodStart    bigquery.NullDate
+	PeriodEnd      bigquery.NullDate
+	PeriodMonths   int
+	Metric         string
+	Value          float64
+	Formula        string
+	FormulaVersion string
+	ComputedAt     time.Time
+}
+
+//standardizedValue is a standardized account as read back from BigQuery
+type standardizedValue struct {
+	CIK           string
+	Account       string
+	PeriodStart   bigquery.NullDate
+	PeriodEnd     bigquery.NullDate
+	PeriodMonths  int
+	FiscalYear    int
+	FiscalQuarter string
+	NumericValue  *big.Rat
+}
+
+//standardizedFactsQuery reads the most recently filed balances and fiscal years of every standardized account, and
+//its quarters from the quarterly-values derive works out: the values of the line items the account was standardized
+//from for the period ending on the same date, so Q4 and the quarters of year to date cash flows are there too
+const standardizedFactsQuery = `SELECT * EXCEPT(ReportNumber) FROM (
+SELECT CIK, Account, PeriodStart, PeriodEnd, PeriodMonths, FiscalYear, FiscalQuarter, NumericValue,
+ROW_NUMBER() OVER (PARTITION BY CIK, Account, PeriodStart, PeriodEnd ORDER BY Year DESC, Quarter DESC, AccessionNumber DESC) AS ReportNumber
+FROM ` + "`%[1]s.SEC.standardized_financials`" + `
+WHERE NumericValue IS NOT NULL AND PeriodMonths IN (0, 12)%[2]s
+) WHERE ReportNumber = 1
+UNION ALL
+SELECT q.CIK, s.Account, q.PeriodStart, q.PeriodEnd, q.PeriodMonths, q.FiscalYear, q.FiscalQuarter, SUM(q.NumericValue) AS NumericValue
+FROM (
+SELECT * EXCEPT(ReportNumber) FROM (
+SELECT CIK, Account, PeriodEnd, Sources,
+ROW_NUMBER() OVER (PARTITION BY CIK, Account, PeriodEnd ORDER BY Year DESC, Quarter DESC, AccessionNumber DESC, PeriodMonths) AS ReportNumber
+FROM ` + "`%[1]s.SEC.standardized_financials`" + `
+WHERE PeriodMonths > 0 AND Statement IN ('IncomeStatement', 'CashFlowStatement')%[2]s
+) WHERE ReportNumber = 1
+) s, UNNEST(s.Sources) AS source
+JOIN ` + "`%[1]s.SEC.quarterly-values`" + ` q
+ON q.CIK = s.CIK AND q.PeriodEnd = s.PeriodEnd AND q.Tag = source.Tag
+AND q.Statement = IF(source.Statement = 'CashFlowStatement', 'cash-flow-statement', 'income-statement')
+WHERE q.Basis = 'Quarter' AND q.IsDefaultContext AND q.NumericValue IS NOT NULL
+GROUP BY q.CIK, s.Account, q.PeriodStart, q.PeriodEnd, q.PeriodMonths, q.FiscalYear, q.FiscalQuarter`
+
+//metricsCommand computes the metrics package's ratios from standardized_financials and quarterly-values into the
+//metrics table, replacing the rows of the current formula version and leaving those of earlier versions
+func metricsCommand(args []string) {
+	flags := flag.NewFlagSet("metrics", flag.ExitOnError)
+	cik := flags.String("cik", "", "only compute metrics for this CIK")
+	flags.Parse(args)
+
+	fmt.Println("Please enter in your Google Cloud project name: ")
+	var projectName string
+	fmt.Scanln(&projectName)
+	ctx := context.Background()
+	bq, err := bigquery.NewClient(ctx, projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bq.Close()
+
+	filter := ""
+	var parameters []bigquery.QueryParameter
+	if *cik != "" {
+		filter = " AND CIK = @cik"
+		parameters = []bigquery.QueryParameter{{Name: "cik", Value: *cik}}
+	}
+	q := bq.Query(fmt.Sprintf(standardizedFactsQuery, projectName, filter))
+	q.Parameters = parameters
+	it, err := q.Read(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var facts []metrics.Fact
+	for {
+		var value standardizedValue
+		err := it.Next(&value)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		facts = append(facts, metrics.Fact{CIK: value.CIK, Account: value.Account, Start: value.PeriodStart.Date, End: value.PeriodEnd.Date, Months: value.PeriodMonths, FiscalYear: value.FiscalYear, FiscalQuarter: value.FiscalQuarter, Value: value.NumericValue})
+	}
+
+	computedAt := time.Now()
+	var metricRows []MetricItem
+	for _, result := range metrics.ComputeAll(facts) {
+		period := result.Period
+		for _, metric := range result.Metrics {
+			metricRows = append(metricRows, MetricItem{CIK: period.CIK, FiscalYear: period.FiscalYear, FiscalQuarter: period.FiscalQuarter, PeriodStart: nullDate(period.Start), PeriodEnd: nullDate(period.End), PeriodMonths: period.Months, Metric: metric.Name, Value: metric.Value, Formula: metric.Formula, FormulaVersion: metrics.FormulaVersion, ComputedAt: computedAt})
+		}
+	}
+	fmt.Println(len(metricRows), "metrics computed from", len(facts), "standardized values")
+
+	metricsTable := bq.Dataset("SEC").Table("metrics")
+	metricsSchema, _ := bigquery.InferSchema(MetricItem{})
+	if err := createTable(ctx, metricsTable, metricsSchema); err != nil {
+		fmt.Println(err)
+	}
+	//Drop what an earlier run of the same formula version computed so reruns don't duplicate metrics. Rows are written
+	//with a load job as streamed rows can't be deleted for a while
+	del := bq.Query(fmt.Sprintf("DELETE FROM `%s.SEC.metrics` WHERE FormulaVersion = @version%s", projectName, filter))
+	del.Parameters = append([]bigquery.QueryParameter{{Name: "version", Value: metrics.FormulaVersion}}, parameters...)
+	if err := runStatement(ctx, del); err != nil {
+		fmt.Println("Can't remove earlier metrics of formula version", metrics.FormulaVersion)
+		log.Fatal(err)
+	}
+	if err := loadRows(ctx, metricsTable, metricsSchema, metricRows, bigquery.WriteAppend); err != nil {
+		fmt.Println("Can't upload metrics")
+		log.Fatal(err)
+	}
+}
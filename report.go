@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/anaskhan96/soup"
+)
+
+//reportRow is a line item of an R page with its value cells in column order
+type reportRow struct {
+	Label      string
+	Tag        string
+	Abstract   string
+	Dimensions []Dimension
+	Cells      []string
+	Footnote   string
+}
+
+//conceptDetails is the definition and xbrl details popup the R page embeds for each concept
+type conceptDetails struct {
+	Definition  string
+	DataType    string
+	BalanceType string
+	PeriodType  string
+}
+
+//parseReportRows walks an R page table the same way the statement parsers do, returning the title,
+//the value columns and every line item with its dimensions and cell text
+func parseReportRows(doc soup.Root) (string, []StatementColumn, []reportRow) {
+	var headerRows []soup.Root
+	var columns []StatementColumn
+	var rows []reportRow
+	var dims dimensionContext
+	columnHeadersFound := false
+	abstract, title := "", ""
+
+	table := doc.Find("table")
+	if table.Error != nil {
+		return title, columns, rows
+	}
+RowLoop:
+	for _, tr := range table.FindAll("tr") {
+		if !columnHeadersFound {
+			if len(tr.FindAll("td")) == 0 {
+				headerRows = append(headerRows, tr)
+				continue
+			}
+			title, columns = parseColumnHeaders(headerRows)
+			columnHeadersFound = true
+		}
+		var row *reportRow
+		for _, value := range tr.FindAll("td") {
+			switch class := value.Attrs()["class"]; class {
+			case "pl ", "pl custom":
+				link := value.Find("a")
+				if link.Error != nil {
+					if dims.expectingMember() {
+						dims.member(strings.TrimSpace(value.FullText()), value.FullText(), true)
+					}
+					continue RowLoop
+				}
+				xbrlTag := strings.Replace(strings.Replace(link.Attrs()["onclick"], "top.Show.showAR( this, '", "", 1), "', window );", "", 1)
+				if strings.Contains(xbrlTag, "Axis") {
+					dims.axis(xbrlTag)
+					continue RowLoop
+				}
+				if isMemberTag(xbrlTag) {
+					dims.member(xbrlTag, value.FullText(), false)
+					continue RowLoop
+				}
+				if strings.Contains(xbrlTag, "Abstract") {
+					abstract = xbrlTag
+					continue RowLoop
+				}
+				row = &reportRow{Label: strings.TrimSpace(value.FullText()), Tag: xbrlTag, Abstract: abstract, Dimensions: dims.lineItem()}
+			case "nump", "num", "text":
+				if row != nil {
+					row.Cells = append(row.Cells, strings.TrimSpace(value.FullText()))
+				}
+			case "th":
+				if row != nil {
+					row.Footnote = strings.TrimSpace(value.FullText())
+				}
+			}
+		}
+		if row != nil {
+			rows = append(rows, *row)
+		}
+	}
+	return title, columns, rows
+}
+
+//parseFootnotes reads the footnotes table under an R page, by the marker ("[1]") line items refer to them with
+func parseFootnotes(doc soup.Root) map[string]string {
+	footnotes := make(map[string]string)
+	table := doc.Find("table", "class", "outerFootnotes")
+	if table.Error != nil {
+		return footnotes
+	}
+	for _, tr := range table.FindAll("tr") {
+		cells := tr.FindAll("td")
+		if len(cells) < 2 {
+			continue
+		}
+		var text []string
+		for _, cell := range cells[1:] {
+			text = append(text, strings.TrimSpace(cell.FullText()))
+		}
+		footnotes[strings.TrimSpace(cells[0].FullText())] = strings.Join(text, " ")
+	}
+	return footnotes
+}
+
+//parseConceptDetails reads the definition, data type, balance type and period type of a concept from the
+//hidden authRefData table at the bottom of the R page
+func parseConceptDetails(doc soup.Root, xbrlTag string) conceptDetails {
+	var details conceptDetails
+	table := doc.Find("table", "id", xbrlTag)
+	if table.Error != nil {
+		return details
+	}
+	div := table.Find("div", "class", "body")
+	if div.Error != nil {
+		return details
+	}
+	if definition := div.Find("div"); definition.Error == nil {
+		if paragraph := definition.Find("p"); paragraph.Error == nil {
+			details.Definition = paragraph.FullText()
+		}
+	}
+	for _, section := range div.FindAll("div") {
+		previous := section.FindPrevElementSibling()
+		if previous.Error != nil || previous.FullText() != "+ Details" {
+			continue
+		}
+		for _, tr := range section.FindAll("tr") {
+			cells := tr.FindAll("td")
+			if len(cells) < 2 {
+				continue
+			}
+			switch strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(cells[0].FullText()), ":")) {
+			case "Data Type":
+				details.DataType = cells[1].Text()
+			case "Balance Type":
+				details.BalanceType = cells[1].Text()
+			case "Period Type":
+				details.PeriodType = cells[1].Text()
+			}
+		}
+	}
+	return details
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+//FilingReport identifies the filing a set of values was reported in and when it became public
+type FilingReport struct {
+	CIK                string
+	AccessionNumber    string
+	Form               string
+	FilingDate         civil.Date
+	AcceptanceDateTime time.Time
+	DocumentPeriodEnd  civil.Date
+}
+
+//NewFilingReport combines the EDGAR index entry of a filing with its SEC header and cover page
+func NewFilingReport(cik string, accessionNumber string, form string, dateFiled string, header SECHeader, cover FilingCover) FilingReport {
+	filing := FilingReport{CIK: cik, AccessionNumber: accessionNumber, Form: form, FilingDate: header.FiledAsOfDate, AcceptanceDateTime: header.AcceptanceDateTime, DocumentPeriodEnd: header.PeriodOfReport}
+	if date, err := civil.ParseDate(dateFiled); err == nil {
+		filing.FilingDate = date
+	}
+	if cover.DocumentPeriodEndDate.Valid {
+		filing.DocumentPeriodEnd = cover.DocumentPeriodEndDate.Date
+	}
+	return filing
+}
+
+//ReportedValue is a value as one filing reported it. The same company, concept, period and dimensions are reported
+//again as comparatives in later filings and by amendments, keeping every report shows when a figure was restated.
+//NumericValue is the value as the statement shows it, Negated is set when it shows the XBRL fact with a negated label
+type ReportedValue struct {
+	CIK                string
+	AccessionNumber    string
+	Form               string
+	IsAmendment        bool
+	FilingDate         bigquery.NullDate
+	AcceptanceDateTime bigquery.NullTimestamp
+	Statement          string
+	Tag                string
+	Concept            string
+	Item               string
+	Dimensions         []Dimension
+	DimensionKey       string
+	Unit               string
+	Currency           string
+	PeriodStart        bigquery.NullDate
+	PeriodEnd          bigquery.NullDate
+	PeriodMonths       int
+	FiscalYear         int
+	FiscalQuarter      string
+	IsCurrentPeriod    bool
+	Value              string
+	NumericValue       *big.Rat `bigquery:",nullable"`
+	Negated            bool
+}
+
+//reportedValue fills in the filing half of a reported value
+func (filing FilingReport) reportedValue(statement string, periodEnd bigquery.NullDate) ReportedValue {
+	value := ReportedValue{CIK: filing.CIK, AccessionNumber: filing.AccessionNumber, Form: filing.Form, IsAmendment: strings.HasSuffix(filing.Form, "/A"), Statement: statement, PeriodEnd: periodEnd, IsCurrentPeriod: periodEnd.Valid && periodEnd.Date == filing.DocumentPeriodEnd}
+	if filing.FilingDate.IsValid() {
+		value.FilingDate = nullDate(filing.FilingDate)
+	}
+	if !filing.AcceptanceDateTime.IsZero() {
+		value.AcceptanceDateTime = bigquery.NullTimestamp{Timestamp: filing.AcceptanceDateTime, Valid: true}
+	}
+	return value
+}
+
+//BalanceSheetReportedValues returns the numeric values of a balance sheet as reported by a filing. negated is the
+//concepts the statement's role presents with a negated label
+func BalanceSheetReportedValues(filing FilingReport, negated map[string]bool, rows []BalanceSheetItem) []ReportedValue {
+	var reportedValues []ReportedValue
+	for _, row := range rows {
+		if row.NumericValue == nil {
+			continue
+		}
+		value := filing.reportedValue(StatementBalanceSheet, row.PeriodEnd)
+		value.Tag, value.Concept, value.Item = row.Tag, xbrlConcept(row.Tag), row.Item
+		value.Dimensions, value.DimensionKey = row.Dimensions, dimensionKey(row.Dimensions)
+		value.Unit, value.Currency = row.Unit, row.Currency
+		value.FiscalYear, value.FiscalQuarter = row.FiscalYear, row.FiscalQuarter
+		value.Value, value.NumericValue, value.Negated = row.Value, row.NumericValue, negated[value.Concept]
+		reportedValues = append(reportedValues, value)
+	}
+	return reportedValues
+}
+
+//IncomeOrCashFlowReportedValues returns the numeric values of an income, comprehensive income or cash flow statement
+//as reported by a filing
+func IncomeOrCashFlowReportedValues(filing FilingReport, statement string, negated map[string]bool, rows []IncomeOrCashFlowStatementItem) []ReportedValue {
+	var reportedValues []ReportedValue
+	for _, row := range rows {
+		if row.NumericValue == nil {
+			continue
+		}
+		value := filing.reportedValue(statement, row.PeriodEnd)
+		value.Tag, value.Concept, value.Item = row.Tag, xbrlConcept(row.Tag), row.Item
+		value.Dimensions, value.DimensionKey = row.Dimensions, dimensionKey(row.Dimensions)
+		value.Unit, value.Currency = row.Unit, row.Currency
+		value.PeriodStart, value.PeriodMonths = row.PeriodStart, row.PeriodMonths
+		value.FiscalYear, value.FiscalQuarter = row.FiscalYear, row.FiscalQuarter
+		value.Value, value.NumericValue, value.Negated = row.Value, row.NumericValue, negated[value.Concept]
+		reportedValues = append(reportedValues, value)
+	}
+	return reportedValues
+}
+
+//reportedValuesWindow is every report of the same figure, oldest first. Filings without an acceptance time are placed
+//at the start of their filing date rather than before every other filing
+const reportedValuesWindow = `PARTITION BY CIK, Concept, DimensionKey, Unit, Currency, PeriodStart, PeriodEnd ORDER BY COALESCE(AcceptanceDateTime, TIMESTAMP(FilingDate)), FilingDate, AccessionNumber`
+
+//FirstReportedViewQuery selects every figure as it was first made public, what was known at the time for backtests
+func FirstReportedViewQuery(projectName string) string {
+	return fmt.Sprintf("SELECT * EXCEPT(ReportNumber) FROM (\n"+
+		"SELECT *, ROW_NUMBER() OVER (%s) AS ReportNumber FROM `%s.SEC.reported_values`\n"+
+		") WHERE ReportNumber = 1", reportedValuesWindow, projectName)
+}
+
+//LatestReportedViewQuery selects every figure as most recently reported, including restatements and amendments,
+//along with the first reported value, how many filings reported it and whether it changed
+func LatestReportedViewQuery(projectName string) string {
+	return fmt.Sprintf("SELECT * EXCEPT(ReportNumber), NumericValue IS DISTINCT FROM FirstReportedNumericValue AS IsRestated FROM (\n"+
+		"SELECT *, ROW_NUMBER() OVER (%[1]s) AS ReportNumber, COUNT(*) OVER (%[1]s ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) AS TimesReported,\n"+
+		"FIRST_VALUE(NumericValue) OVER (%[1]s ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) AS FirstReportedNumericValue,\n"+
+		"FIRST_VALUE(AccessionNumber) OVER (%[1]s ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) AS FirstReportedAccessionNumber\n"+
+		"FROM `%[2]s.SEC.reported_values`\n"+
+		") WHERE ReportNumber = TimesReported", reportedValuesWindow, projectName)
+}
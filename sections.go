@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/anaskhan96/soup"
+	"golang.org/x/net/html"
+)
+
+//NarrativeSection is the clean text of an item of a 10-K or 10-Q, e.g. Item 1A Risk Factors. Part is only needed to
+//tell the items of a 10-Q apart (Part I Item 1 is the financial statements, Part II Item 1 legal proceedings)
+type NarrativeSection struct {
+	Year            string
+	Quarter         string
+	CIK             string
+	AccessionNumber string
+	Form            string
+	FilingDate      bigquery.NullDate
+	PeriodOfReport  bigquery.NullDate
+	Document        string
+	Part            string
+	Item            string
+	Title           string
+	Heading         string
+	Position        int
+	Text            string
+	WordCount       int
+}
+
+//annualReportItems and quarterlyReportItems are the titles of the items of a 10-K and a 10-Q by part and item
+var annualReportItems = map[string]string{
+	"I 1":    "Business",
+	"I 1A":   "Risk Factors",
+	"I 1B":   "Unresolved Staff Comments",
+	"I 1C":   "Cybersecurity",
+	"I 2":    "Properties",
+	"I 3":    "Legal Proceedings",
+	"I 4":    "Mine Safety Disclosures",
+	"II 5":   "Market for Registrant's Common Equity, Related Stockholder Matters and Issuer Purchases of Equity Securities",
+	"II 6":   "Selected Financial Data",
+	"II 7":   "Management's Discussion and Analysis of Financial Condition and Results of Operations",
+	"II 7A":  "Quantitative and Qualitative Disclosures About Market Risk",
+	"II 8":   "Financial Statements and Supplementary Data",
+	"II 9":   "Changes in and Disagreements with Accountants on Accounting and Financial Disclosure",
+	"II 9A":  "Controls and Procedures",
+	"II 9B":  "Other Information",
+	"II 9C":  "Disclosure Regarding Foreign Jurisdictions that Prevent Inspections",
+	"III 10": "Directors, Executive Officers and Corporate Governance",
+	"III 11": "Executive Compensation",
+	"III 12": "Security Ownership of Certain Beneficial Owners and Management and Related Stockholder Matters",
+	"III 13": "Certain Relationships and Related Transactions, and Director Independence",
+	"III 14": "Principal Accountant Fees and Services",
+	"IV 15":  "Exhibits and Financial Statement Schedules",
+	"IV 16":  "Form 10-K Summary",
+}
+
+var quarterlyReportItems = map[string]string{
+	"I 1":   "Financial Statements",
+	"I 2":   "Management's Discussion and Analysis of Financial Condition and Results of Operations",
+	"I 3":   "Quantitative and Qualitative Disclosures About Market Risk",
+	"I 4":   "Controls and Procedures",
+	"II 1":  "Legal Proceedings",
+	"II 1A": "Risk Factors",
+	"II 2":  "Unregistered Sales of Equity Securities and Use of Proceeds",
+	"II 3":  "Defaults Upon Senior Securities",
+	"II 4":  "Mine Safety Disclosures",
+	"II 5":  "Other Information",
+	"II 6":  "Exhibits",
+}
+
+//annualReportPart is the part of a 10-K an item belongs to, 10-K item numbers are unique across parts
+func annualReportPart(item string) string {
+	number := 0
+	fmt.Sscanf(item, "%d", &number)
+	switch {
+	case number <= 4:
+		return "I"
+	case number <= 9:
+		return "II"
+	case number <= 14:
+		return "III"
+	default:
+		return "IV"
+	}
+}
+
+var (
+	//itemHeadingPattern matches a line that starts an item, "Item 1A. Risk Factors", "ITEM 7 — MANAGEMENT'S ..." or
+	//"Part II, Item 1A" on its own. An item number followed by lower case text is a reference in a sentence
+	itemHeadingPattern = regexp.MustCompile(`^(?:(?i:part\s+(i{1,3}|iv))\s*[,.:\-–—]?\s*)?(?i:items?)\s+(\d{1,2}(?i:[a-c])?)(?:\s*(?i:and|&|,)\s*\d{1,2}(?i:[a-c])?)*\s*(?:[.:\-–—]\s*|$|\s+(\p{Lu}.*))(.*)$`)
+	partHeadingPattern = regexp.MustCompile(`(?i)^part\s+(i{1,3}|iv)\b[\s.,:\-–—]*([^\d]*)$`)
+	pageNumberPattern  = regexp.MustCompile(`(?i)^(?:page\s*)?[\-–—\s]*(?:\d{1,3}|[ivx]{1,5}|[a-z]-\d{1,3})[\-–—\s]*$`)
+	digitsPattern      = regexp.MustCompile(`\d+`)
+	signaturesPattern  = regexp.MustCompile(`(?i)^signatures?$`)
+	wordPattern        = regexp.MustCompile(`\S+`)
+)
+
+//maxHeadingLength is the longest line taken for an item heading, longer lines are paragraphs that mention an item
+const maxHeadingLength = 200
+
+//sectionLines flattens a document to lines, dropping page numbers, "Table of Contents" links and the running page
+//headers and footers. A running header is a short line with a page number repeated on many pages, e.g.
+//"Apple Inc. | 2023 Form 10-K | 17", table rows are kept as the same line item can repeat across tables
+func sectionLines(text string) []string {
+	var lines []string
+	repeats := make(map[string]int)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || pageNumberPattern.MatchString(line) || strings.EqualFold(line, "table of contents") {
+			continue
+		}
+		lines = append(lines, line)
+		if isRunningHeaderCandidate(line) {
+			repeats[digitsPattern.ReplaceAllString(strings.ToLower(line), "#")]++
+		}
+	}
+	kept := lines[:0]
+	for _, line := range lines {
+		if isRunningHeaderCandidate(line) && repeats[digitsPattern.ReplaceAllString(strings.ToLower(line), "#")] >= 3 {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+//isRunningHeaderCandidate reports whether a line could be a page header or footer, item and part headings are not
+//however alike "Item 1." and "Item 2." are once their numbers are ignored
+func isRunningHeaderCandidate(line string) bool {
+	return len(line) <= 100 && !strings.Contains(line, "\t") && digitsPattern.MatchString(line) &&
+		!itemHeadingPattern.MatchString(line) && !partHeadingPattern.MatchString(line)
+}
+
+//sectionHeading is a line that starts an item, or a part when item is empty. Contents headings are the entries of
+//the table of contents, which end in their page number
+type sectionHeading struct {
+	line     int
+	part     string
+	item     string
+	title    string
+	contents bool
+}
+
+//pageNumberSuffix is the page number a table of contents line ends with
+var pageNumberSuffix = regexp.MustCompile(`\s+\d{1,3}$`)
+
+//findSectionHeadings lists every line that looks like a part or item heading, in the table of contents as well as
+//the body. Parts are taken from the PART headings of 10-Qs and from the item number of 10-Ks
+func findSectionHeadings(lines []string, annual bool) []sectionHeading {
+	var headings []sectionHeading
+	part := "I"
+	for i, line := range lines {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "\t", " "))
+		if len(line) > maxHeadingLength {
+			continue
+		}
+		if match := partHeadingPattern.FindStringSubmatch(line); match != nil {
+			part = strings.ToUpper(match[1])
+			headings = append(headings, sectionHeading{line: i, part: part})
+			continue
+		}
+		match := itemHeadingPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		heading := sectionHeading{line: i, part: part, item: strings.ToUpper(match[2]), title: strings.TrimSpace(match[3] + match[4]), contents: pageNumberSuffix.MatchString(line)}
+		if match[1] != "" {
+			heading.part = strings.ToUpper(match[1])
+			part = heading.part
+		}
+		if annual {
+			heading.part = annualReportPart(heading.item)
+		}
+		//headings split over two lines, "Item 1A." then "Risk Factors"
+		if heading.title == "" && i+1 < len(lines) && len(lines[i+1]) <= maxHeadingLength && itemHeadingPattern.FindStringSubmatch(lines[i+1]) == nil {
+			heading.title = strings.TrimSpace(strings.ReplaceAll(lines[i+1], "\t", " "))
+			heading.contents = pageNumberSuffix.MatchString(heading.title)
+		}
+		heading.title = strings.TrimRight(strings.TrimSpace(pageNumberSuffix.ReplaceAllString(heading.title, "")), ".")
+		headings = append(headings, heading)
+	}
+	return headings
+}
+
+//SegmentSections splits the text of a 10-K or 10-Q into its items. An item's heading appears in the table of
+//contents too, and sometimes in cross references set on their own line, so of the headings of an item the one outside
+//the table of contents followed by the most text before the next heading is taken as the item. An item runs until
+//the next item taken or part heading, the last one until the signatures
+func SegmentSections(text string, form string) []NarrativeSection {
+	annual := strings.HasPrefix(form, "10-K")
+	titles := quarterlyReportItems
+	if annual {
+		titles = annualReportItems
+	}
+	lines := sectionLines(text)
+	headings := findSectionHeadings(lines, annual)
+	end := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if signaturesPattern.MatchString(lines[i]) {
+			if len(headings) == 0 || i > headings[len(headings)-1].line {
+				end = i
+			}
+			break
+		}
+	}
+	//next is where the text after a heading ends, at the following heading of any kind or at a part heading only
+	next := func(h int, partsOnly bool, taken map[int]bool) int {
+		for _, heading := range headings[h+1:] {
+			if !partsOnly || heading.item == "" || taken[heading.line] {
+				return heading.line
+			}
+		}
+		return end
+	}
+	length := func(h int) int {
+		size := 0
+		for _, line := range lines[headings[h].line+1 : next(h, false, nil)] {
+			size += len(line)
+		}
+		return size
+	}
+
+	best := make(map[string]int)
+	for h, heading := range headings {
+		key := heading.part + " " + heading.item
+		if _, ok := titles[key]; !ok {
+			continue
+		}
+		previous, ok := best[key]
+		switch {
+		case !ok:
+			best[key] = h
+		case heading.contents != headings[previous].contents:
+			if !heading.contents {
+				best[key] = h
+			}
+		case length(h) > length(previous):
+			best[key] = h
+		}
+	}
+	var chosen []int
+	taken := make(map[int]bool)
+	for _, h := range best {
+		chosen = append(chosen, h)
+		taken[headings[h].line] = true
+	}
+	sort.Ints(chosen)
+
+	var sections []NarrativeSection
+	for i, h := range chosen {
+		heading := headings[h]
+		body := strings.TrimSpace(strings.Join(lines[heading.line+1:next(h, true, taken)], "\n"))
+		if heading.title != "" && strings.HasPrefix(body, heading.title) {
+			body = strings.TrimSpace(strings.TrimPrefix(body, heading.title))
+		}
+		section := NarrativeSection{Form: form, Part: heading.part, Item: heading.item, Title: titles[heading.part+" "+heading.item], Heading: heading.title, Position: i + 1, Text: body, WordCount: len(wordPattern.FindAllString(body, -1))}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+//PrimaryDocumentName finds the main document of a filing, the one of the form's type, in the Document Format Files
+//table of its -index.htm page. Inline xbrl documents are linked through the viewer so the name is read from the link
+func PrimaryDocumentName(indexPage []byte, form string) (string, bool) {
+	doc := soup.HTMLParse(string(indexPage))
+	table := doc.Find("table", "class", "tableFile")
+	if table.Error != nil {
+		return "", false
+	}
+	for _, tr := range table.FindAll("tr") {
+		cells := tr.FindAll("td")
+		if len(cells) < 4 || strings.TrimSpace(cells[3].FullText()) != form {
+			continue
+		}
+		link := cells[2].Find("a")
+		if link.Error != nil {
+			continue
+		}
+		href := link.Attrs()["href"]
+		if i := strings.LastIndex(href, "/"); i >= 0 {
+			href = href[i+1:]
+		}
+		if href != "" {
+			return href, true
+		}
+	}
+	return "", false
+}
+
+//FilingIndexURL is the -index.htm page of a filing listing its documents and their types
+func FilingIndexURL(filingDirectoryIndexURL string, accessionNumber string) string {
+	return filingDirectoryIndexURL + "/" + accessionNumber + "-index.htm"
+}
+
+//ParseNarrativeSections reads the primary html document of a 10-K or 10-Q into its items
+func ParseNarrativeSections(document []byte, documentName string, year string, qtr string, cik string, accessionNumber string, form string, dateFiled string, periodOfReport bigquery.NullDate) []NarrativeSection {
+	node, err := html.Parse(strings.NewReader(string(document)))
+	if err != nil {
+		return nil
+	}
+	sections := SegmentSections(noteText(node), form)
+	for i := range sections {
+		sections[i].Year, sections[i].Quarter, sections[i].CIK, sections[i].AccessionNumber = year, qtr, cik, accessionNumber
+		sections[i].FilingDate, sections[i].PeriodOfReport, sections[i].Document = xmlDate(dateFiled), periodOfReport, documentName
+	}
+	return sections
+}
+
+//NarrativeChangesViewQuery pairs every 10-K item with the same item of the company's previous 10-K, so sections
+//such as risk factors can be compared year over year. Amendments are left out as they rarely restate the narrative
+func NarrativeChangesViewQuery(projectName string) string {
+	return fmt.Sprintf("SELECT CIK, Item, Title, PeriodOfReport, AccessionNumber, Text, WordCount,\n"+
+		"  LAG(PeriodOfReport) OVER filings AS PreviousPeriodOfReport,\n"+
+		"  LAG(AccessionNumber) OVER filings AS PreviousAccessionNumber,\n"+
+		"  LAG(Text) OVER filings AS PreviousText,\n"+
+		"  LAG(WordCount) OVER filings AS PreviousWordCount,\n"+
+		"  Text != LAG(Text) OVER filings AS Changed\n"+
+		"FROM `%s.SEC.narrative_sections`\n"+
+		"WHERE Form = '10-K'\n"+
+		"WINDOW filings AS (PARTITION BY CIK, Item ORDER BY PeriodOfReport, FilingDate)", projectName)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+//appleAnnualReport is laid out like the text of Apple's 2023 10-K: a table of contents with page numbers, a running
+//footer on every page and headings with the item and its title on one line
+var appleAnnualReport = strings.Join([]string{
+	"UNITED STATES",
+	"SECURITIES AND EXCHANGE COMMISSION",
+	"FORM 10-K",
+	"Apple Inc.",
+	"TABLE OF CONTENTS",
+	"Page",
+	"Part I",
+	"Item 1.\tBusiness\t1",
+	"Item 1A.\tRisk Factors\t5",
+	"Item 7.\tManagement’s Discussion and Analysis of Financial Condition and Results of Operations\t20",
+	"Part I",
+	"Item 1. Business",
+	"Company Background",
+	"The Company designs, manufactures and markets smartphones, personal computers, tablets, wearables and accessories, and sells a variety of related services.",
+	"Apple Inc. | 2023 Form 10-K | 1",
+	"Item 1A. Risk Factors",
+	"The Company’s business, reputation, results of operations, financial condition and stock price can be affected by a number of factors, as described in Part II, Item 7 of this Form 10-K under the heading “Management’s Discussion and Analysis.”",
+	"Apple Inc. | 2023 Form 10-K | 5",
+	"Table of Contents",
+	"Part II",
+	"Item 7. Management’s Discussion and Analysis of Financial Condition and Results of Operations",
+	"The following discussion should be read in conjunction with the consolidated financial statements.",
+	"21",
+	"Apple Inc. | 2023 Form 10-K | 20",
+	"SIGNATURES",
+	"Pursuant to the requirements of Section 13 or 15(d) of the Securities Exchange Act of 1934, the Registrant has duly caused this report to be signed.",
+}, "\n")
+
+//microsoftQuarterlyReport is laid out like the text of a Microsoft 10-Q: items numbered again in each part and headings
+//split over two lines
+var microsoftQuarterlyReport = strings.Join([]string{
+	"PART I. FINANCIAL INFORMATION",
+	"ITEM 1.",
+	"FINANCIAL STATEMENTS",
+	"INCOME STATEMENTS",
+	"Revenue\t56,517\t52,857",
+	"PART I",
+	"Item 2",
+	"ITEM 2. MANAGEMENT’S DISCUSSION AND ANALYSIS OF FINANCIAL CONDITION AND RESULTS OF OPERATIONS",
+	"Revenue increased $3.7 billion or 7% driven by growth in Microsoft Cloud.",
+	"PART II. OTHER INFORMATION",
+	"ITEM 1A. RISK FACTORS",
+	"Our operations and financial results are subject to various risks and uncertainties, including those described below.",
+	"ITEM 6. EXHIBITS",
+	"31.1\tCertification of Chief Executive Officer",
+	"SIGNATURES",
+	"Pursuant to the requirements of the Securities Exchange Act of 1934, the Registrant has duly caused this report to be signed.",
+}, "\n")
+
+func TestSegmentSections(t *testing.T) {
+	type want struct {
+		part    string
+		item    string
+		title   string
+		heading string
+		text    string
+	}
+	tests := []struct {
+		name string
+		text string
+		form string
+		want []want
+	}{
+		{
+			name: "10-K items are taken from the body rather than the table of contents and lose their footers",
+			text: appleAnnualReport,
+			form: "10-K",
+			want: []want{
+				{"I", "1", "Business", "Business", "Company Background\nThe Company designs, manufactures and markets smartphones, personal computers, tablets, wearables and accessories, and sells a variety of related services."},
+				{"I", "1A", "Risk Factors", "Risk Factors", "The Company’s business, reputation, results of operations, financial condition and stock price can be affected by a number of factors, as described in Part II, Item 7 of this Form 10-K under the heading “Management’s Discussion and Analysis.”"},
+				{"II", "7", "Management's Discussion and Analysis of Financial Condition and Results of Operations", "Management’s Discussion and Analysis of Financial Condition and Results of Operations", "The following discussion should be read in conjunction with the consolidated financial statements."},
+			},
+		},
+		{
+			name: "10-Q items are told apart by part and headings split over two lines keep their title",
+			text: microsoftQuarterlyReport,
+			form: "10-Q",
+			want: []want{
+				{"I", "1", "Financial Statements", "FINANCIAL STATEMENTS", "INCOME STATEMENTS\nRevenue\t56,517\t52,857"},
+				{"I", "2", "Management's Discussion and Analysis of Financial Condition and Results of Operations", "MANAGEMENT’S DISCUSSION AND ANALYSIS OF FINANCIAL CONDITION AND RESULTS OF OPERATIONS", "Revenue increased $3.7 billion or 7% driven by growth in Microsoft Cloud."},
+				{"II", "1A", "Risk Factors", "RISK FACTORS", "Our operations and financial results are subject to various risks and uncertainties, including those described below."},
+				{"II", "6", "Exhibits", "EXHIBITS", "31.1\tCertification of Chief Executive Officer"},
+			},
+		},
+		{
+			name: "a document without item headings has no sections",
+			text: "Apple Inc. hereby files this amendment to include the exhibits listed below.\nSIGNATURES",
+			form: "10-K/A",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sections := SegmentSections(test.text, test.form)
+			if len(sections) != len(test.want) {
+				t.Fatalf("got %d sections %+v, want %d", len(sections), sections, len(test.want))
+			}
+			for i, section := range sections {
+				w := test.want[i]
+				if section.Part != w.part || section.Item != w.item || section.Title != w.title || section.Heading != w.heading {
+					t.Errorf("section %d = Part %s Item %s %q %q, want Part %s Item %s %q %q", i, section.Part, section.Item, section.Title, section.Heading, w.part, w.item, w.title, w.heading)
+				}
+				if section.Text != w.text {
+					t.Errorf("section %d text = %q, want %q", i, section.Text, w.text)
+				}
+				if section.Position != i+1 || section.Form != test.form || section.WordCount != len(strings.Fields(w.text)) {
+					t.Errorf("section %d = Position %d Form %s WordCount %d", i, section.Position, section.Form, section.WordCount)
+				}
+			}
+		})
+	}
+}
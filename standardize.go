@@ -0,0 +1,255 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//go:embed standard_accounts.json
+var defaultMappingRules []byte
+
+//MappingRule maps the line items of a statement to a canonical account. Every criterion given must hold: the
+//concept is one of Concepts, its calculation parent one of CalculationParents, its lowercase label contains one of
+//LabelPatterns and its balance type is BalanceType, while a label containing any of the Exclusions never matches.
+//Sum adds up every matching line item instead of taking the first one
+type MappingRule struct {
+	Account            string   `json:"account"`
+	Statement          string   `json:"statement"`
+	Concepts           []string `json:"concepts"`
+	CalculationParents []string `json:"calculationParents"`
+	LabelPatterns      []string `json:"labelPatterns"`
+	Exclusions         []string `json:"exclusions"`
+	BalanceType        string   `json:"balanceType"`
+	Sum                bool     `json:"sum"`
+	Priority           int      `json:"priority"`
+}
+
+type MappingRules struct {
+	Rules []MappingRule `json:"rules"`
+}
+
+//StatementFact is a numeric line item of a statement reported without dimensions, what the mapping rules run over
+type StatementFact struct {
+	Statement     string
+	Role          string
+	Tag           string
+	Concept       string
+	Item          string
+	BalanceType   string
+	Unit          string
+	Currency      string
+	PeriodStart   bigquery.NullDate
+	PeriodEnd     bigquery.NullDate
+	PeriodMonths  int
+	FiscalYear    int
+	FiscalQuarter string
+	NumericValue  *big.Rat
+}
+
+//StandardizedSource is a line item that fed a standardized value
+type StandardizedSource struct {
+	Statement         string
+	Tag               string
+	Concept           string
+	Item              string
+	CalculationParent string
+	NumericValue      *big.Rat `bigquery:",nullable"`
+}
+
+//StandardizedItem is the value of a canonical account (Revenue, TotalAssets, CashFromOperations...) for one period
+//of a filing, with the rule that picked it and the line items it came from
+type StandardizedItem struct {
+	Year            string
+	Quarter         string
+	CIK             string
+	AccessionNumber string
+	FiscalYear      int
+	FiscalQuarter   string
+	Account         string
+	Statement       string
+	PeriodStart     bigquery.NullDate
+	PeriodEnd       bigquery.NullDate
+	PeriodMonths    int
+	NumericValue    *big.Rat `bigquery:",nullable"`
+	Unit            string
+	Currency        string
+	MatchedBy       string
+	RulePriority    int
+	Sources         []StandardizedSource
+}
+
+//DefaultMappingRules returns the chart of accounts shipped with the ETL in standard_accounts.json
+func DefaultMappingRules() MappingRules {
+	var rules MappingRules
+	if err := json.Unmarshal(defaultMappingRules, &rules); err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+//LoadMappingRules reads a mapping rules file in the same format as standard_accounts.json
+func LoadMappingRules(path string) (MappingRules, error) {
+	var rules MappingRules
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	err = json.Unmarshal(file, &rules)
+	return rules, err
+}
+
+//BalanceSheetFacts returns the numeric line items of a balance sheet that carry no dimensions
+func BalanceSheetFacts(role string, rows []BalanceSheetItem) []StatementFact {
+	var facts []StatementFact
+	for _, row := range rows {
+		if row.NumericValue == nil || !row.IsDefaultContext {
+			continue
+		}
+		facts = append(facts, StatementFact{Statement: StatementBalanceSheet, Role: role, Tag: row.Tag, Concept: xbrlConcept(row.Tag), Item: row.Item, BalanceType: row.BalanceType, Unit: row.Unit, Currency: row.Currency, PeriodEnd: row.PeriodEnd, FiscalYear: row.FiscalYear, FiscalQuarter: row.FiscalQuarter, NumericValue: row.NumericValue})
+	}
+	return facts
+}
+
+//IncomeOrCashFlowFacts returns the numeric line items of an income, comprehensive income or cash flow statement
+//that carry no dimensions
+func IncomeOrCashFlowFacts(statement string, role string, rows []IncomeOrCashFlowStatementItem) []StatementFact {
+	var facts []StatementFact
+	for _, row := range rows {
+		if row.NumericValue == nil || !row.IsDefaultContext {
+			continue
+		}
+		facts = append(facts, StatementFact{Statement: statement, Role: role, Tag: row.Tag, Concept: xbrlConcept(row.Tag), Item: row.Item, BalanceType: row.BalanceType, Unit: row.Unit, Currency: row.Currency, PeriodStart: row.PeriodStart, PeriodEnd: row.PeriodEnd, PeriodMonths: row.PeriodMonths, FiscalYear: row.FiscalYear, FiscalQuarter: row.FiscalQuarter, NumericValue: row.NumericValue})
+	}
+	return facts
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+//matchMappingRule tells whether a line item satisfies every criterion of a rule
+func matchMappingRule(fact StatementFact, rule MappingRule, calculations CalculationLinkbase) bool {
+	label := strings.ToLower(fact.Item)
+	if rule.Statement != "" && fact.Statement != rule.Statement {
+		return false
+	}
+	if len(rule.Concepts) > 0 && !containsString(rule.Concepts, fact.Concept) {
+		return false
+	}
+	if len(rule.CalculationParents) > 0 {
+		arc, ok := calculations.Parent(fact.Role, fact.Concept)
+		if !ok || !containsString(rule.CalculationParents, arc.Parent) {
+			return false
+		}
+	}
+	if len(rule.LabelPatterns) > 0 && !containsAny(label, rule.LabelPatterns) {
+		return false
+	}
+	if rule.BalanceType != "" && fact.BalanceType != rule.BalanceType {
+		return false
+	}
+	return !containsAny(label, rule.Exclusions)
+}
+
+//matchedBy names the criteria a rule matched on for the audit trail
+func (rule MappingRule) matchedBy() string {
+	var criteria []string
+	if len(rule.Concepts) > 0 {
+		criteria = append(criteria, "Concept")
+	}
+	if len(rule.CalculationParents) > 0 {
+		criteria = append(criteria, "CalculationParent")
+	}
+	if len(rule.LabelPatterns) > 0 {
+		criteria = append(criteria, "Label")
+	}
+	if rule.BalanceType != "" {
+		criteria = append(criteria, "BalanceType")
+	}
+	return strings.Join(criteria, "+")
+}
+
+//conceptRank orders matches by how early their concept is listed in the rule, so a rule can prefer a total
+//(us-gaap:Revenues) over a narrower concept the same filing also reports
+func conceptRank(rule MappingRule, concept string) int {
+	for i, listed := range rule.Concepts {
+		if listed == concept {
+			return i
+		}
+	}
+	return len(rule.Concepts)
+}
+
+//Standardize maps the statement line items of a filing onto the canonical accounts of the rules. For every
+//account and period the highest priority rule with a match decides the value
+func Standardize(facts []StatementFact, calculations CalculationLinkbase, rules MappingRules, year string, qtr string, cik string, accessionNumber string) []StandardizedItem {
+	var accounts []string
+	accountRules := make(map[string][]MappingRule)
+	for _, rule := range rules.Rules {
+		if _, ok := accountRules[rule.Account]; !ok {
+			accounts = append(accounts, rule.Account)
+		}
+		accountRules[rule.Account] = append(accountRules[rule.Account], rule)
+	}
+
+	var periods [][2]bigquery.NullDate
+	periodFacts := make(map[[2]bigquery.NullDate][]StatementFact)
+	for _, fact := range facts {
+		period := [2]bigquery.NullDate{fact.PeriodStart, fact.PeriodEnd}
+		if _, ok := periodFacts[period]; !ok {
+			periods = append(periods, period)
+		}
+		periodFacts[period] = append(periodFacts[period], fact)
+	}
+
+	var standardizedRows []StandardizedItem
+	for _, account := range accounts {
+		candidates := accountRules[account]
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Priority > candidates[j].Priority })
+		for _, period := range periods {
+			for _, rule := range candidates {
+				var matches []StatementFact
+				for _, fact := range periodFacts[period] {
+					if matchMappingRule(fact, rule, calculations) {
+						matches = append(matches, fact)
+					}
+				}
+				if len(matches) == 0 {
+					continue
+				}
+				sort.SliceStable(matches, func(i, j int) bool {
+					return conceptRank(rule, matches[i].Concept) < conceptRank(rule, matches[j].Concept)
+				})
+				if !rule.Sum {
+					matches = matches[:1]
+				}
+				standardizedRow := StandardizedItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, FiscalYear: matches[0].FiscalYear, FiscalQuarter: matches[0].FiscalQuarter, Account: account, Statement: matches[0].Statement, PeriodStart: period[0], PeriodEnd: period[1], PeriodMonths: matches[0].PeriodMonths, NumericValue: new(big.Rat), Unit: matches[0].Unit, Currency: matches[0].Currency, MatchedBy: rule.matchedBy(), RulePriority: rule.Priority}
+				summed := make(map[string]bool)
+				for _, match := range matches {
+					//the same concept can show up twice on a statement (e.g. net income on the cash flow statement)
+					if summed[match.Concept] {
+						continue
+					}
+					summed[match.Concept] = true
+					standardizedRow.NumericValue.Add(standardizedRow.NumericValue, match.NumericValue)
+					arc, _ := calculations.Parent(match.Role, match.Concept)
+					standardizedRow.Sources = append(standardizedRow.Sources, StandardizedSource{Statement: match.Statement, Tag: match.Tag, Concept: match.Concept, Item: match.Item, CalculationParent: arc.Parent, NumericValue: match.NumericValue})
+				}
+				standardizedRows = append(standardizedRows, standardizedRow)
+				break
+			}
+		}
+	}
+	return standardizedRows
+}
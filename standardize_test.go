@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+const (
+	testOperationsRole = "http://www.example.com/role/ConsolidatedStatementsOfOperations"
+	testRevenueRole    = "http://www.example.com/role/RevenueDetails"
+)
+
+//extensionRevenueLinkbase places two extension concepts under us-gaap:Revenues, the products line on the statement
+//of operations and the services line only in a revenue note, as filers often split their calculations
+const extensionRevenueLinkbase = `<?xml version="1.0" encoding="utf-8"?>
+<link:linkbase xmlns:link="http://www.xbrl.org/2003/linkbase" xmlns:xlink="http://www.w3.org/1999/xlink">
+  <link:calculationLink xlink:role="http://www.example.com/role/ConsolidatedStatementsOfOperations" xlink:type="extended">
+    <link:loc xlink:type="locator" xlink:href="http://xbrl.fasb.org/us-gaap/2019/elts/us-gaap-2019-01-31.xsd#us-gaap_Revenues" xlink:label="loc_us-gaap_Revenues"/>
+    <link:loc xlink:type="locator" xlink:href="xyz-20191228.xsd#xyz_ProductsRevenue" xlink:label="loc_xyz_ProductsRevenue"/>
+    <link:loc xlink:type="locator" xlink:href="xyz-20191228.xsd#xyz_MarketingExpense" xlink:label="loc_xyz_MarketingExpense"/>
+    <link:loc xlink:type="locator" xlink:href="http://xbrl.fasb.org/us-gaap/2019/elts/us-gaap-2019-01-31.xsd#us-gaap_SellingGeneralAndAdministrativeExpense" xlink:label="loc_us-gaap_SellingGeneralAndAdministrativeExpense"/>
+    <link:calculationArc xlink:type="arc" xlink:arcrole="http://www.xbrl.org/2003/arcrole/summation-item" xlink:from="loc_us-gaap_Revenues" xlink:to="loc_xyz_ProductsRevenue" weight="1.0" order="1"/>
+    <link:calculationArc xlink:type="arc" xlink:arcrole="http://www.xbrl.org/2003/arcrole/summation-item" xlink:from="loc_us-gaap_SellingGeneralAndAdministrativeExpense" xlink:to="loc_xyz_MarketingExpense" weight="1.0" order="1"/>
+  </link:calculationLink>
+  <link:calculationLink xlink:role="http://www.example.com/role/RevenueDetails" xlink:type="extended">
+    <link:loc xlink:type="locator" xlink:href="http://xbrl.fasb.org/us-gaap/2019/elts/us-gaap-2019-01-31.xsd#us-gaap_Revenues" xlink:label="loc_us-gaap_Revenues"/>
+    <link:loc xlink:type="locator" xlink:href="xyz-20191228.xsd#xyz_ServicesRevenue" xlink:label="loc_xyz_ServicesRevenue"/>
+    <link:calculationArc xlink:type="arc" xlink:arcrole="http://www.xbrl.org/2003/arcrole/summation-item" xlink:from="loc_us-gaap_Revenues" xlink:to="loc_xyz_ServicesRevenue" weight="1.0" order="1"/>
+  </link:calculationLink>
+</link:linkbase>`
+
+var (
+	testQuarterStart = bigquery.NullDate{Date: civil.Date{Year: 2019, Month: time.September, Day: 29}, Valid: true}
+	testQuarterEnd   = bigquery.NullDate{Date: civil.Date{Year: 2019, Month: time.December, Day: 28}, Valid: true}
+	testPriorStart   = bigquery.NullDate{Date: civil.Date{Year: 2018, Month: time.September, Day: 30}, Valid: true}
+	testPriorEnd     = bigquery.NullDate{Date: civil.Date{Year: 2018, Month: time.December, Day: 29}, Valid: true}
+)
+
+//operationsFact is a line item of the statement of operations for the quarter ended Dec. 28, 2019
+func operationsFact(concept string, item string, balanceType string, value int64) StatementFact {
+	return StatementFact{Statement: StatementIncomeStatement, Role: testOperationsRole, Tag: concept, Concept: concept, Item: item, BalanceType: balanceType, Unit: UnitMonetary, Currency: "USD", PeriodStart: testQuarterStart, PeriodEnd: testQuarterEnd, PeriodMonths: 3, FiscalYear: 2020, FiscalQuarter: "Q1", NumericValue: big.NewRat(value, 1)}
+}
+
+func TestStandardize(t *testing.T) {
+	type source struct {
+		concept string
+		parent  string
+	}
+	tests := []struct {
+		name      string
+		facts     []StatementFact
+		account   string
+		value     string
+		matchedBy string
+		priority  int
+		sources   []source
+	}{
+		{
+			name:      "a listed concept maps to its account",
+			facts:     []StatementFact{operationsFact("us-gaap:RevenueFromContractWithCustomerExcludingAssessedTax", "Total net sales", "credit", 91819)},
+			account:   "Revenue",
+			value:     "91819",
+			matchedBy: "Concept",
+			priority:  10,
+			sources:   []source{{"us-gaap:RevenueFromContractWithCustomerExcludingAssessedTax", ""}},
+		},
+		{
+			name: "the concept listed first wins over a narrower one",
+			facts: []StatementFact{
+				operationsFact("us-gaap:RevenueFromContractWithCustomerExcludingAssessedTax", "Revenue from contracts with customers", "credit", 80000),
+				operationsFact("us-gaap:Revenues", "Total revenues", "credit", 91819),
+			},
+			account:   "Revenue",
+			value:     "91819",
+			matchedBy: "Concept",
+			priority:  10,
+			sources:   []source{{"us-gaap:Revenues", ""}},
+		},
+		{
+			name: "an extension concept is matched on its label and balance type, costs excluded",
+			facts: []StatementFact{
+				operationsFact("xyz:CostOfNetSales", "Cost of net sales", "credit", 56602),
+				operationsFact("xyz:NetSales", "Net sales", "credit", 91819),
+			},
+			account:   "Revenue",
+			value:     "91819",
+			matchedBy: "Label+BalanceType",
+			priority:  5,
+			sources:   []source{{"xyz:NetSales", ""}},
+		},
+		{
+			name: "extension concepts are summed into the total the calculation linkbase places them under",
+			facts: []StatementFact{
+				operationsFact("xyz:ProductsRevenue", "Products", "credit", 79104),
+				operationsFact("xyz:ServicesRevenue", "Services", "credit", 12715),
+				operationsFact("xyz:MarketingExpense", "Marketing", "debit", 1000),
+			},
+			account:   "Revenue",
+			value:     "91819",
+			matchedBy: "CalculationParent",
+			priority:  1,
+			sources:   []source{{"xyz:ProductsRevenue", "us-gaap:Revenues"}, {"xyz:ServicesRevenue", "us-gaap:Revenues"}},
+		},
+		{
+			name: "a listed concept outranks extension concepts placed under the same total",
+			facts: []StatementFact{
+				operationsFact("xyz:MarketingExpense", "Marketing", "debit", 1000),
+				operationsFact("us-gaap:SellingGeneralAndAdministrativeExpense", "Selling, general and administrative", "debit", 5197),
+			},
+			account:   "SellingGeneralAndAdministrative",
+			value:     "5197",
+			matchedBy: "Concept",
+			priority:  10,
+			sources:   []source{{"us-gaap:SellingGeneralAndAdministrativeExpense", ""}},
+		},
+		{
+			name:    "an extension concept outside the linkbase and the label patterns isn't mapped",
+			facts:   []StatementFact{operationsFact("xyz:WearablesRevenue", "Wearables, Home and Accessories", "credit", 10010)},
+			account: "Revenue",
+		},
+	}
+	calculations := ParseCalculationLinkbase([]byte(extensionRevenueLinkbase))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []StandardizedItem
+			for _, item := range Standardize(test.facts, calculations, DefaultMappingRules(), "2020", "QTR1", "320193", "0000320193-20-000010") {
+				if item.Account == test.account {
+					got = append(got, item)
+				}
+			}
+			if test.value == "" {
+				if len(got) != 0 {
+					t.Fatalf("got %+v, want no %s", got, test.account)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d %s values %+v, want 1", len(got), test.account, got)
+			}
+			item := got[0]
+			if item.NumericValue.RatString() != test.value || item.MatchedBy != test.matchedBy || item.RulePriority != test.priority {
+				t.Errorf("got %s by %s priority %d, want %s by %s priority %d", item.NumericValue.RatString(), item.MatchedBy, item.RulePriority, test.value, test.matchedBy, test.priority)
+			}
+			if item.FiscalYear != 2020 || item.FiscalQuarter != "Q1" || item.PeriodMonths != 3 || item.AccessionNumber != "0000320193-20-000010" {
+				t.Errorf("got %+v, want the period and filing of its line items", item)
+			}
+			var sources []source
+			for _, s := range item.Sources {
+				sources = append(sources, source{s.Concept, s.CalculationParent})
+			}
+			if !reflect.DeepEqual(sources, test.sources) {
+				t.Errorf("sources = %+v, want %+v", sources, test.sources)
+			}
+		})
+	}
+}
+
+func TestStandardizeByPeriod(t *testing.T) {
+	current := operationsFact("us-gaap:NetIncomeLoss", "Net income", "credit", 22236)
+	prior := operationsFact("us-gaap:NetIncomeLoss", "Net income", "credit", 19965)
+	prior.PeriodStart, prior.PeriodEnd, prior.FiscalYear = testPriorStart, testPriorEnd, 2019
+	//net income shows up again in the operating activities of the cash flow statement
+	cashFlow := current
+	cashFlow.Statement = StatementCashFlowStatement
+	items := Standardize([]StatementFact{current, prior, current}, CalculationLinkbase{}, DefaultMappingRules(), "2020", "QTR1", "320193", "0000320193-20-000010")
+	items = append(items, Standardize([]StatementFact{cashFlow}, CalculationLinkbase{}, DefaultMappingRules(), "2020", "QTR1", "320193", "0000320193-20-000010")...)
+	want := map[string]map[int]string{"NetIncome": {2020: "22236", 2019: "19965"}, "NetIncomeCashFlow": {2020: "22236"}}
+	got := make(map[string]map[int]string)
+	for _, item := range items {
+		if got[item.Account] == nil {
+			got[item.Account] = make(map[int]string)
+		}
+		got[item.Account][item.FiscalYear] = item.NumericValue.RatString()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//SubmissionsAddress is a business or mailing address in a submissions document
+type SubmissionsAddress struct {
+	Street1        string `json:"street1"`
+	City           string `json:"city"`
+	StateOrCountry string `json:"stateOrCountry"`
+	ZipCode        string `json:"zipCode"`
+}
+
+//SubmissionsFormerName is a name the company filed under before, with when it was used
+type SubmissionsFormerName struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+//SubmissionsFilings is a column per field of a company's filings, the recent filings of a submissions document or
+//a CIK##########-submissions-###.json page of older ones
+type SubmissionsFilings struct {
+	AccessionNumber       []string `json:"accessionNumber"`
+	FilingDate            []string `json:"filingDate"`
+	ReportDate            []string `json:"reportDate"`
+	AcceptanceDateTime    []string `json:"acceptanceDateTime"`
+	Form                  []string `json:"form"`
+	Items                 []string `json:"items"`
+	IsXBRL                []int    `json:"isXBRL"`
+	IsInlineXBRL          []int    `json:"isInlineXBRL"`
+	PrimaryDocument       []string `json:"primaryDocument"`
+	PrimaryDocDescription []string `json:"primaryDocDescription"`
+}
+
+//SubmissionsFile is a page of older filings listed in a submissions document
+type SubmissionsFile struct {
+	Name        string `json:"name"`
+	FilingCount int    `json:"filingCount"`
+	FilingFrom  string `json:"filingFrom"`
+	FilingTo    string `json:"filingTo"`
+}
+
+//SubmissionsCompany is the company half of a submissions document: its details without the filings
+type SubmissionsCompany struct {
+	CIK                  json.Number                   `json:"cik"`
+	EntityType           string                        `json:"entityType"`
+	SIC                  string                        `json:"sic"`
+	SICDescription       string                        `json:"sicDescription"`
+	Name                 string                        `json:"name"`
+	Tickers              []string                      `json:"tickers"`
+	Exchanges            []string                      `json:"exchanges"`
+	EIN                  string                        `json:"ein"`
+	FiscalYearEnd        string                        `json:"fiscalYearEnd"`
+	StateOfIncorporation string                        `json:"stateOfIncorporation"`
+	Addresses            map[string]SubmissionsAddress `json:"addresses"`
+	FormerNames          []SubmissionsFormerName       `json:"formerNames"`
+}
+
+//SubmissionsDocument is a CIK##########.json document from the SEC's submissions api, a company's details and its
+//most recent filings, with older filings paginated into the files it lists
+type SubmissionsDocument struct {
+	SubmissionsCompany
+	Filings struct {
+		Recent SubmissionsFilings `json:"recent"`
+		Files  []SubmissionsFile  `json:"files"`
+	} `json:"filings"`
+}
+
+//ParseSubmissions reads a submissions document
+func ParseSubmissions(data []byte) (SubmissionsDocument, error) {
+	var document SubmissionsDocument
+	err := json.Unmarshal(data, &document)
+	return document, err
+}
+
+//ParseSubmissionsCompany reads the company details of a submissions document, leaving out its filings
+func ParseSubmissionsCompany(data []byte) (SubmissionsCompany, error) {
+	var company SubmissionsCompany
+	err := json.Unmarshal(data, &company)
+	return company, err
+}
+
+//ParseSubmissionsFilings reads a page of older filings
+func ParseSubmissionsFilings(data []byte) (SubmissionsFilings, error) {
+	var filings SubmissionsFilings
+	err := json.Unmarshal(data, &filings)
+	return filings, err
+}
+
+//SubmissionsFileName is the name of a company's submissions document, CIK0000320193.json for 320193
+func SubmissionsFileName(cik string) string {
+	number, _ := strconv.Atoi(normalizeCIK(cik))
+	return fmt.Sprintf("CIK%010d.json", number)
+}
+
+//LoadSubmissionsDir reads the company details of the CIK##########.json documents in a directory, e.g. the unzipped
+//submissions.zip bulk download, without keeping their filings. The CIK##########-submissions-###.json pages of older
+//filings carry no company details and are skipped
+func LoadSubmissionsDir(dir string) ([]SubmissionsCompany, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "CIK*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var companies []SubmissionsCompany
+	for _, path := range paths {
+		if strings.Contains(filepath.Base(path), "-submissions-") {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		company, err := ParseSubmissionsCompany(data)
+		if err != nil {
+			return nil, err
+		}
+		companies = append(companies, company)
+	}
+	return companies, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//createTable creates a table for the schema of a row type. A table created by an earlier version gets the columns
+//the row type gained since, so writing the new rows doesn't fail on unknown fields. It returns the create error,
+//as it always did, when the table already matches
+func createTable(ctx context.Context, table *bigquery.Table, schema bigquery.Schema) error {
+	err := table.Create(ctx, &bigquery.TableMetadata{Schema: schema})
+	if err == nil {
+		return nil
+	}
+	metadata, metadataErr := table.Metadata(ctx)
+	if metadataErr != nil {
+		return err
+	}
+	migrated, changed := migrateSchema(metadata.Schema, schema)
+	if !changed {
+		return err
+	}
+	_, err = table.Update(ctx, bigquery.TableMetadataToUpdate{Schema: migrated}, metadata.ETag)
+	return err
+}
+
+//migrateSchema appends the fields of schema missing from existing, records included. BigQuery only adds nullable
+//columns, and columns the row type dropped (like the Axis of the first statement tables) are made nullable so rows
+//without them can be written
+func migrateSchema(existing bigquery.Schema, schema bigquery.Schema) (bigquery.Schema, bool) {
+	fields := make(map[string]*bigquery.FieldSchema)
+	for _, field := range schema {
+		fields[strings.ToLower(field.Name)] = field
+	}
+	changed := false
+	var migrated bigquery.Schema
+	seen := make(map[string]bool)
+	for _, field := range existing {
+		copied := *field
+		name := strings.ToLower(field.Name)
+		seen[name] = true
+		inferred, ok := fields[name]
+		if copied.Required && (!ok || !inferred.Required) {
+			copied.Required = false
+			changed = true
+		}
+		if ok && copied.Type == bigquery.RecordFieldType && inferred.Type == bigquery.RecordFieldType {
+			var nestedChanged bool
+			copied.Schema, nestedChanged = migrateSchema(field.Schema, inferred.Schema)
+			changed = changed || nestedChanged
+		}
+		migrated = append(migrated, &copied)
+	}
+	for _, field := range schema {
+		if seen[strings.ToLower(field.Name)] {
+			continue
+		}
+		migrated = append(migrated, nullableField(field))
+		changed = true
+	}
+	return migrated, changed
+}
+
+//nullableField copies a field, and the fields of a record, with every REQUIRED mode relaxed
+func nullableField(field *bigquery.FieldSchema) *bigquery.FieldSchema {
+	copied := *field
+	copied.Required = false
+	copied.Schema = nil
+	for _, nested := range field.Schema {
+		copied.Schema = append(copied.Schema, nullableField(nested))
+	}
+	return &copied
+}
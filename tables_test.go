@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//baselineBalanceSheetItem is the balance sheet row before dimensions, numeric values and periods were added
+type baselineBalanceSheetItem struct {
+	Year        string
+	Quarter     string
+	CIK         string
+	Title       string
+	Date        string
+	Item        string
+	Value       string
+	Axis        string
+	Abstract    string
+	Tag         string
+	Definition  string
+	DataType    string
+	BalanceType string
+	PeriodType  string
+	Footnote    string
+}
+
+func TestMigrateSchema(t *testing.T) {
+	existing, _ := bigquery.InferSchema(baselineBalanceSheetItem{})
+	schema, _ := bigquery.InferSchema(BalanceSheetItem{})
+	migrated, changed := migrateSchema(existing, schema)
+	if !changed {
+		t.Fatal("the baseline schema wasn't migrated")
+	}
+	fields := make(map[string]*bigquery.FieldSchema)
+	for i, field := range migrated {
+		if i < len(existing) && field.Name != existing[i].Name {
+			t.Errorf("field %d = %s, the existing columns must keep their order", i, field.Name)
+		}
+		fields[field.Name] = field
+	}
+	if len(migrated) != len(schema)+1 {
+		t.Errorf("got %d fields, want the %d of the row and Axis", len(migrated), len(schema)+1)
+	}
+	tests := []struct {
+		name     string
+		required bool
+		repeated bool
+	}{
+		{"Year", true, false},
+		{"Axis", false, false},
+		{"IsDefaultContext", false, false},
+		{"Dimensions", false, true},
+	}
+	for _, test := range tests {
+		field, ok := fields[test.name]
+		if !ok {
+			t.Errorf("%s is missing", test.name)
+			continue
+		}
+		if field.Required != test.required || field.Repeated != test.repeated {
+			t.Errorf("%s Required = %v Repeated = %v, want %v %v", test.name, field.Required, field.Repeated, test.required, test.repeated)
+		}
+	}
+	for _, nested := range fields["Dimensions"].Schema {
+		if nested.Required {
+			t.Errorf("Dimensions.%s is required, added columns must be nullable", nested.Name)
+		}
+	}
+
+	if _, changed := migrateSchema(migrated, schema); changed {
+		t.Error("a migrated schema was migrated again")
+	}
+}
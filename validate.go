@@ -0,0 +1,229 @@
+package main
+
+import (
+	"math/big"
+
+	"cloud.google.com/go/bigquery"
+)
+
+//Accounting identities checked on every filing
+const (
+	CheckBalanceSheet       = "AssetsEqualLiabilitiesAndEquity"
+	CheckCashReconciliation = "CashReconciles"
+	CheckNetIncome          = "NetIncomeAgrees"
+)
+
+//Outcomes of a check, Incomplete when the filing doesn't report (or the parser didn't find) every input
+const (
+	ValidationPassed     = "Passed"
+	ValidationFailed     = "Failed"
+	ValidationIncomplete = "Incomplete"
+)
+
+//validationTolerance is the relative difference allowed for rounding, statements are usually rounded to millions
+var validationTolerance = big.NewRat(1, 1000)
+
+//ValidationItem is the outcome of an accounting identity check for one period of a filing
+type ValidationItem struct {
+	Year            string
+	Quarter         string
+	CIK             string
+	AccessionNumber string
+	Check           string
+	PeriodStart     bigquery.NullDate
+	PeriodEnd       bigquery.NullDate
+	Status          string
+	Formula         string
+	Expected        *big.Rat `bigquery:",nullable"`
+	Actual          *big.Rat `bigquery:",nullable"`
+	Delta           *big.Rat `bigquery:",nullable"`
+}
+
+//QuarantinedFiling is a filing held back from the statement tables because it failed a check
+type QuarantinedFiling struct {
+	Year            string
+	Quarter         string
+	CIK             string
+	AccessionNumber string
+	FailedChecks    []string
+}
+
+//standardizedPeriod is the standardized accounts of one period of a filing
+type standardizedPeriod struct {
+	start, end bigquery.NullDate
+	accounts   map[string]StandardizedItem
+}
+
+func (p standardizedPeriod) value(account string) (*big.Rat, bool) {
+	item, ok := p.accounts[account]
+	if !ok || item.NumericValue == nil {
+		return nil, false
+	}
+	return item.NumericValue, true
+}
+
+//hasStatement reports whether any account of the period comes from a statement
+func (p standardizedPeriod) hasStatement(statement string) bool {
+	for _, item := range p.accounts {
+		if item.Statement == statement {
+			return true
+		}
+	}
+	return false
+}
+
+//sum adds up accounts, failing when any of them is missing
+func (p standardizedPeriod) sum(accounts ...string) (*big.Rat, bool) {
+	total := new(big.Rat)
+	for _, account := range accounts {
+		value, ok := p.value(account)
+		if !ok {
+			return nil, false
+		}
+		total.Add(total, value)
+	}
+	return total, true
+}
+
+//withinTolerance reports whether two amounts agree up to rounding
+func withinTolerance(expected *big.Rat, actual *big.Rat) bool {
+	delta := new(big.Rat).Sub(actual, expected)
+	if delta.Sign() == 0 {
+		return true
+	}
+	size := new(big.Rat).Abs(expected)
+	if a := new(big.Rat).Abs(actual); a.Cmp(size) > 0 {
+		size = a
+	}
+	return new(big.Rat).Abs(delta).Cmp(new(big.Rat).Mul(size, validationTolerance)) <= 0
+}
+
+//standardizedPeriods groups the standardized accounts of a filing by period
+func standardizedPeriods(standardizedRows []StandardizedItem) []standardizedPeriod {
+	var periods []standardizedPeriod
+	index := make(map[[2]bigquery.NullDate]int)
+	for _, row := range standardizedRows {
+		key := [2]bigquery.NullDate{row.PeriodStart, row.PeriodEnd}
+		i, ok := index[key]
+		if !ok {
+			i = len(periods)
+			index[key] = i
+			periods = append(periods, standardizedPeriod{start: row.PeriodStart, end: row.PeriodEnd, accounts: make(map[string]StandardizedItem)})
+		}
+		periods[i].accounts[row.Account] = row
+	}
+	return periods
+}
+
+//balanceSheetCheck compares total assets to total liabilities and equity. When equity was taken from the parent's
+//stockholders' equity alone, noncontrolling interests are added to it
+func balanceSheetCheck(p standardizedPeriod) (string, *big.Rat, *big.Rat, bool) {
+	assets, ok := p.value("TotalAssets")
+	if !ok {
+		return "TotalAssets = TotalLiabilities + TotalEquity", nil, nil, false
+	}
+	if total, ok := p.value("LiabilitiesAndEquity"); ok {
+		return "TotalAssets = LiabilitiesAndEquity", total, assets, true
+	}
+	total, ok := p.sum("TotalLiabilities", "TotalEquity")
+	if !ok {
+		return "TotalAssets = TotalLiabilities + TotalEquity", nil, assets, false
+	}
+	equity := p.accounts["TotalEquity"]
+	if nci, ok := p.value("NoncontrollingInterest"); ok && len(equity.Sources) > 0 && equity.Sources[0].Concept == "us-gaap:StockholdersEquity" {
+		total.Add(total, nci)
+		return "TotalAssets = TotalLiabilities + StockholdersEquity + NoncontrollingInterest", total, assets, true
+	}
+	return "TotalAssets = TotalLiabilities + TotalEquity", total, assets, true
+}
+
+//cashReconciliationCheck rolls beginning cash (including restricted cash when the filer reports it that way) forward
+//by the net change for the period. Filers differ on whether the net change includes exchange rate effects, so it is
+//tried both ways, and the change is rebuilt from the three activities and the exchange rate effect when not reported
+func cashReconciliationCheck(p standardizedPeriod) (string, *big.Rat, *big.Rat, bool) {
+	formula := "EndingCash = BeginningCash + NetChangeInCash"
+	beginning, ok := p.value("BeginningCash")
+	ending, ok2 := p.value("EndingCash")
+	if !ok || !ok2 {
+		return formula, nil, ending, false
+	}
+	fx, hasFX := p.value("EffectOfExchangeRate")
+	if change, ok := p.value("NetChangeInCash"); ok {
+		expected := new(big.Rat).Add(beginning, change)
+		if hasFX && !withinTolerance(expected, ending) {
+			if withFX := new(big.Rat).Add(expected, fx); withinTolerance(withFX, ending) {
+				return formula + " + EffectOfExchangeRate", withFX, ending, true
+			}
+		}
+		return formula, expected, ending, true
+	}
+	formula = "EndingCash = BeginningCash + CashFromOperations + CashFromInvesting + CashFromFinancing"
+	change, ok := p.sum("CashFromOperations", "CashFromInvesting", "CashFromFinancing")
+	if !ok {
+		return formula, nil, ending, false
+	}
+	expected := new(big.Rat).Add(beginning, change)
+	if hasFX {
+		formula += " + EffectOfExchangeRate"
+		expected.Add(expected, fx)
+	}
+	return formula, expected, ending, true
+}
+
+//netIncomeCheck compares net income on the income statement to the net income the cash flow statement starts from
+func netIncomeCheck(p standardizedPeriod) (string, *big.Rat, *big.Rat, bool) {
+	incomeStatement, ok := p.value("NetIncome")
+	cashFlow, ok2 := p.value("NetIncomeCashFlow")
+	return "NetIncome = NetIncomeCashFlow", incomeStatement, cashFlow, ok && ok2
+}
+
+//accountingCheck is an identity over the standardized accounts of a period, returning the formula it used, the
+//expected and actual amounts and whether every input was there. It applies to periods with accounts of its statement
+type accountingCheck struct {
+	name      string
+	statement string
+	run       func(standardizedPeriod) (string, *big.Rat, *big.Rat, bool)
+}
+
+var accountingChecks = []accountingCheck{
+	{name: CheckBalanceSheet, statement: StatementBalanceSheet, run: balanceSheetCheck},
+	{name: CheckCashReconciliation, statement: StatementCashFlowStatement, run: cashReconciliationCheck},
+	{name: CheckNetIncome, statement: StatementCashFlowStatement, run: netIncomeCheck},
+}
+
+//ValidateFiling runs the accounting identity checks over the standardized accounts of a filing, balance sheet checks
+//for every balance sheet date and cash flow checks for every cash flow statement period
+func ValidateFiling(standardizedRows []StandardizedItem, year string, qtr string, cik string, accessionNumber string) []ValidationItem {
+	var validationRows []ValidationItem
+	for _, period := range standardizedPeriods(standardizedRows) {
+		for _, check := range accountingChecks {
+			if !period.hasStatement(check.statement) {
+				continue
+			}
+			formula, expected, actual, ok := check.run(period)
+			validationRow := ValidationItem{Year: year, Quarter: qtr, CIK: cik, AccessionNumber: accessionNumber, Check: check.name, PeriodStart: period.start, PeriodEnd: period.end, Status: ValidationIncomplete, Formula: formula, Expected: expected, Actual: actual}
+			if ok {
+				validationRow.Delta = new(big.Rat).Sub(actual, expected)
+				validationRow.Status = ValidationFailed
+				if withinTolerance(expected, actual) {
+					validationRow.Status = ValidationPassed
+				}
+			}
+			validationRows = append(validationRows, validationRow)
+		}
+	}
+	return validationRows
+}
+
+//FailedChecks lists the checks a filing failed, a filing with any is quarantined
+func FailedChecks(validationRows []ValidationItem) []string {
+	var failed []string
+	seen := make(map[string]bool)
+	for _, validationRow := range validationRows {
+		if validationRow.Status == ValidationFailed && !seen[validationRow.Check] {
+			seen[validationRow.Check] = true
+			failed = append(failed, validationRow.Check)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+//testPeriod builds the standardized accounts of a period in millions, equity taken from equityConcept
+func testPeriod(equityConcept string, values map[string]int64) standardizedPeriod {
+	period := standardizedPeriod{accounts: make(map[string]StandardizedItem)}
+	for account, value := range values {
+		item := StandardizedItem{Account: account, NumericValue: big.NewRat(value, 1)}
+		if account == "TotalEquity" {
+			item.Sources = []StandardizedSource{{Concept: equityConcept, NumericValue: item.NumericValue}}
+		}
+		period.accounts[account] = item
+	}
+	return period
+}
+
+func ratString(value *big.Rat) string {
+	if value == nil {
+		return ""
+	}
+	return value.RatString()
+}
+
+func TestWithinTolerance(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected *big.Rat
+		actual   *big.Rat
+		want     bool
+	}{
+		{"equal", big.NewRat(338516, 1), big.NewRat(338516, 1), true},
+		{"rounding within a tenth of a percent", big.NewRat(100000, 1), big.NewRat(100100, 1), true},
+		{"just over a tenth of a percent", big.NewRat(100000, 1), big.NewRat(100101, 1), false},
+		{"the larger amount sets the tolerance", big.NewRat(100100, 1), big.NewRat(100000, 1), true},
+		{"negative amounts", big.NewRat(-5000, 1), big.NewRat(-5004, 1), true},
+		{"zero against an amount", new(big.Rat), big.NewRat(1, 1), false},
+		{"both zero", new(big.Rat), new(big.Rat), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := withinTolerance(test.expected, test.actual); got != test.want {
+				t.Errorf("withinTolerance(%s, %s) = %v, want %v", test.expected.RatString(), test.actual.RatString(), got, test.want)
+			}
+		})
+	}
+}
+
+func TestBalanceSheetCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		equity   string
+		values   map[string]int64
+		formula  string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "total liabilities and equity reported",
+			values:   map[string]int64{"TotalAssets": 338516, "LiabilitiesAndEquity": 338516, "TotalLiabilities": 248028},
+			formula:  "TotalAssets = LiabilitiesAndEquity",
+			expected: "338516",
+			ok:       true,
+		},
+		{
+			name:     "equity including noncontrolling interests isn't added to again",
+			equity:   "us-gaap:StockholdersEquityIncludingPortionAttributableToNoncontrollingInterest",
+			values:   map[string]int64{"TotalAssets": 1000, "TotalLiabilities": 600, "TotalEquity": 400, "NoncontrollingInterest": 50},
+			formula:  "TotalAssets = TotalLiabilities + TotalEquity",
+			expected: "1000",
+			ok:       true,
+		},
+		{
+			name:     "noncontrolling interests are added to the parent's stockholders' equity",
+			equity:   "us-gaap:StockholdersEquity",
+			values:   map[string]int64{"TotalAssets": 1000, "TotalLiabilities": 600, "TotalEquity": 350, "NoncontrollingInterest": 50},
+			formula:  "TotalAssets = TotalLiabilities + StockholdersEquity + NoncontrollingInterest",
+			expected: "1000",
+			ok:       true,
+		},
+		{
+			name:     "stockholders' equity without noncontrolling interests",
+			equity:   "us-gaap:StockholdersEquity",
+			values:   map[string]int64{"TotalAssets": 1000, "TotalLiabilities": 600, "TotalEquity": 350},
+			formula:  "TotalAssets = TotalLiabilities + TotalEquity",
+			expected: "950",
+			ok:       true,
+		},
+		{
+			name:    "no total liabilities",
+			equity:  "us-gaap:StockholdersEquity",
+			values:  map[string]int64{"TotalAssets": 1000, "TotalEquity": 350},
+			formula: "TotalAssets = TotalLiabilities + TotalEquity",
+		},
+		{
+			name:    "no total assets",
+			values:  map[string]int64{"LiabilitiesAndEquity": 1000},
+			formula: "TotalAssets = TotalLiabilities + TotalEquity",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formula, expected, _, ok := balanceSheetCheck(testPeriod(test.equity, test.values))
+			if formula != test.formula || ratString(expected) != test.expected || ok != test.ok {
+				t.Errorf("got %q %s %v, want %q %s %v", formula, ratString(expected), ok, test.formula, test.expected, test.ok)
+			}
+		})
+	}
+}
+
+func TestCashReconciliationCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]int64
+		formula  string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "net change reported",
+			values:   map[string]int64{"BeginningCash": 25913, "NetChangeInCash": 24311, "EndingCash": 50224},
+			formula:  "EndingCash = BeginningCash + NetChangeInCash",
+			expected: "50224",
+			ok:       true,
+		},
+		{
+			name:     "net change including the exchange rate effect",
+			values:   map[string]int64{"BeginningCash": 1000, "NetChangeInCash": 180, "EffectOfExchangeRate": -20, "EndingCash": 1180},
+			formula:  "EndingCash = BeginningCash + NetChangeInCash",
+			expected: "1180",
+			ok:       true,
+		},
+		{
+			name:     "exchange rate effect added to a net change excluding it",
+			values:   map[string]int64{"BeginningCash": 1000, "NetChangeInCash": 200, "EffectOfExchangeRate": -20, "EndingCash": 1180},
+			formula:  "EndingCash = BeginningCash + NetChangeInCash + EffectOfExchangeRate",
+			expected: "1180",
+			ok:       true,
+		},
+		{
+			name:     "neither way reconciles",
+			values:   map[string]int64{"BeginningCash": 1000, "NetChangeInCash": 300, "EffectOfExchangeRate": -20, "EndingCash": 1180},
+			formula:  "EndingCash = BeginningCash + NetChangeInCash",
+			expected: "1300",
+			ok:       true,
+		},
+		{
+			name:     "net change rebuilt from the three activities",
+			values:   map[string]int64{"BeginningCash": 1000, "CashFromOperations": 500, "CashFromInvesting": -200, "CashFromFinancing": -100, "EndingCash": 1200},
+			formula:  "EndingCash = BeginningCash + CashFromOperations + CashFromInvesting + CashFromFinancing",
+			expected: "1200",
+			ok:       true,
+		},
+		{
+			name:     "net change rebuilt with the exchange rate effect",
+			values:   map[string]int64{"BeginningCash": 1000, "CashFromOperations": 500, "CashFromInvesting": -200, "CashFromFinancing": -100, "EffectOfExchangeRate": -20, "EndingCash": 1180},
+			formula:  "EndingCash = BeginningCash + CashFromOperations + CashFromInvesting + CashFromFinancing + EffectOfExchangeRate",
+			expected: "1180",
+			ok:       true,
+		},
+		{
+			name:    "an activity missing",
+			values:  map[string]int64{"BeginningCash": 1000, "CashFromOperations": 500, "CashFromInvesting": -200, "EndingCash": 1200},
+			formula: "EndingCash = BeginningCash + CashFromOperations + CashFromInvesting + CashFromFinancing",
+		},
+		{
+			name:    "no beginning cash",
+			values:  map[string]int64{"NetChangeInCash": 200, "EndingCash": 1200},
+			formula: "EndingCash = BeginningCash + NetChangeInCash",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formula, expected, _, ok := cashReconciliationCheck(testPeriod("", test.values))
+			if formula != test.formula || ratString(expected) != test.expected || ok != test.ok {
+				t.Errorf("got %q %s %v, want %q %s %v", formula, ratString(expected), ok, test.formula, test.expected, test.ok)
+			}
+		})
+	}
+}
+
+func TestValidateFiling(t *testing.T) {
+	item := func(statement string, account string, value int64) StandardizedItem {
+		return StandardizedItem{Statement: statement, Account: account, PeriodEnd: testQuarterEnd, NumericValue: big.NewRat(value, 1)}
+	}
+	cashFlow := func(account string, value int64) StandardizedItem {
+		row := item(StatementCashFlowStatement, account, value)
+		row.PeriodStart = testQuarterStart
+		return row
+	}
+	rows := []StandardizedItem{
+		item(StatementBalanceSheet, "TotalAssets", 1000),
+		item(StatementBalanceSheet, "LiabilitiesAndEquity", 1100),
+		cashFlow("BeginningCash", 100),
+		cashFlow("NetChangeInCash", 50),
+		cashFlow("EndingCash", 150),
+		cashFlow("NetIncomeCashFlow", 80),
+	}
+	statuses := make(map[string]string)
+	for _, row := range ValidateFiling(rows, "2020", "QTR1", "320193", "0000320193-20-000010") {
+		statuses[row.Check] = row.Status
+		if row.Status == ValidationFailed && ratString(row.Delta) != "-100" {
+			t.Errorf("%s Delta = %s, want -100", row.Check, ratString(row.Delta))
+		}
+	}
+	want := map[string]string{CheckBalanceSheet: ValidationFailed, CheckCashReconciliation: ValidationPassed, CheckNetIncome: ValidationIncomplete}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("got %v, want %v", statuses, want)
+	}
+	if failed := FailedChecks(ValidateFiling(rows, "2020", "QTR1", "320193", "0000320193-20-000010")); !reflect.DeepEqual(failed, []string{CheckBalanceSheet}) {
+		t.Errorf("FailedChecks = %v, want the balance sheet check", failed)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+//Units a statement value can be reported in
+const (
+	UnitMonetary = "monetary"
+	UnitPerShare = "perShare"
+	UnitShares   = "shares"
+	UnitPure     = "pure"
+	UnitOther    = "other"
+)
+
+//StatementScale holds the currency and multipliers stated in a statement header,
+//e.g. "USD ($) shares in Thousands, $ in Millions" or "In Thousands, except Per Share data"
+type StatementScale struct {
+	Currency      string
+	MonetaryScale int64
+	ShareScale    int64
+	PerShareScale int64
+}
+
+//NormalizedValue is a statement cell converted to a signed, scaled number so values from different filers are comparable
+type NormalizedValue struct {
+	Number   *big.Rat
+	Unit     string
+	Currency string
+	Scale    int64
+}
+
+var (
+	currencyPattern     = regexp.MustCompile(`\(?\b([A-Z]{3}) \(?[^)\s]+\)`)
+	unitScalePattern    = regexp.MustCompile(`(\$ / shares|shares|\$|€|£|¥|[A-Z]{3}) in (Units|Thousands|Millions|Billions)`)
+	legacyScalePattern  = regexp.MustCompile(`\bIn (Thousands|Millions|Billions)`)
+	legacyExceptPattern = regexp.MustCompile(`(?i)except (Per Share|Share|Shares)( data)?( in (Thousands|Millions|Billions))?`)
+	footnoteRefPattern  = regexp.MustCompile(`\[\d+\]`)
+	currencySymbols     = map[string]string{"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY"}
+)
+
+func scaleMultiplier(word string) int64 {
+	switch strings.ToLower(word) {
+	case "thousands":
+		return 1000
+	case "millions":
+		return 1000000
+	case "billions":
+		return 1000000000
+	}
+	return 1
+}
+
+//ParseStatementScale reads the currency and scale from the title cell of a statement
+func ParseStatementScale(title string) StatementScale {
+	scale := StatementScale{MonetaryScale: 1, ShareScale: 1, PerShareScale: 1}
+	if match := currencyPattern.FindStringSubmatch(title); match != nil {
+		scale.Currency = match[1]
+	}
+
+	//current renderer format: "USD ($) $ / shares in Units, shares in Thousands, $ in Millions"
+	for _, match := range unitScalePattern.FindAllStringSubmatch(title, -1) {
+		multiplier := scaleMultiplier(match[2])
+		switch strings.ToLower(match[1]) {
+		case "$ / shares":
+			scale.PerShareScale = multiplier
+		case "shares":
+			scale.ShareScale = multiplier
+		default:
+			scale.MonetaryScale = multiplier
+		}
+	}
+
+	//older renderer format: "In Thousands, except Per Share data, unless otherwise specified"
+	if match := legacyScalePattern.FindStringSubmatch(title); match != nil {
+		multiplier := scaleMultiplier(match[1])
+		scale.MonetaryScale = multiplier
+		scale.ShareScale = multiplier
+		if except := legacyExceptPattern.FindStringSubmatch(title); except != nil {
+			if strings.EqualFold(except[1], "Per Share") {
+				scale.PerShareScale = 1
+			} else {
+				scale.ShareScale = scaleMultiplier(except[4])
+			}
+		}
+	}
+	return scale
+}
+
+//valueUnit classifies a row from its xbrl data type, falling back to the label the filer gave it
+func valueUnit(dataType string, label string) string {
+	dataType = strings.ToLower(dataType)
+	label = strings.ToLower(label)
+	switch {
+	case strings.Contains(dataType, "pershare"):
+		return UnitPerShare
+	case strings.Contains(dataType, "shares"):
+		return UnitShares
+	case strings.Contains(dataType, "monetary"):
+		return UnitMonetary
+	case strings.Contains(dataType, "percent"), strings.Contains(dataType, "pure"):
+		return UnitPure
+	case strings.Contains(label, "per share"):
+		return UnitPerShare
+	case strings.Contains(label, "(in shares)"):
+		return UnitShares
+	}
+	return UnitOther
+}
+
+//NormalizeValue converts the text of a statement cell like "$ (1,234)" into a decimal with the correct sign,
+//applies the header scale for the kind of row it is on and detects the currency
+func NormalizeValue(text string, dataType string, label string, scale StatementScale) NormalizedValue {
+	normalized := NormalizedValue{Unit: valueUnit(dataType, label), Scale: 1}
+	cell := strings.TrimSpace(footnoteRefPattern.ReplaceAllString(text, ""))
+	if cell == "" {
+		return normalized
+	}
+
+	if normalized.Unit == UnitMonetary || normalized.Unit == UnitPerShare {
+		normalized.Currency = scale.Currency
+		for symbol, currency := range currencySymbols {
+			if strings.Contains(cell, symbol) {
+				if normalized.Currency == "" {
+					normalized.Currency = currency
+				}
+				break
+			}
+		}
+	}
+
+	negative := strings.Contains(cell, "(") && strings.Contains(cell, ")")
+	percent := strings.HasSuffix(cell, "%")
+	cell = strings.NewReplacer("(", "", ")", "", ",", "", "%", "", " ", "", " ", "").Replace(cell)
+	for symbol := range currencySymbols {
+		cell = strings.ReplaceAll(cell, symbol, "")
+	}
+	for _, dash := range []string{"—", "–", "-"} {
+		if cell == dash {
+			cell = "0"
+		}
+	}
+
+	number, ok := new(big.Rat).SetString(cell)
+	if !ok {
+		return normalized
+	}
+	if negative {
+		number.Neg(number)
+	}
+	if percent {
+		number.Quo(number, big.NewRat(100, 1))
+	}
+
+	switch normalized.Unit {
+	case UnitMonetary:
+		normalized.Scale = scale.MonetaryScale
+	case UnitShares:
+		normalized.Scale = scale.ShareScale
+	case UnitPerShare:
+		normalized.Scale = scale.PerShareScale
+	}
+	normalized.Number = number.Mul(number, new(big.Rat).SetInt64(normalized.Scale))
+	return normalized
+}
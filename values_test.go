@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestParseStatementScale(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  StatementScale
+	}{
+		{"millions of dollars", "CONSOLIDATED BALANCE SHEETS - USD ($) $ in Millions", StatementScale{"USD", 1000000, 1, 1}},
+		{"shares in thousands and per share in units", "CONSOLIDATED STATEMENTS OF OPERATIONS - USD ($) $ / shares in Units, shares in Thousands, $ in Millions", StatementScale{"USD", 1000000, 1000, 1}},
+		{"no scale", "Document and Entity Information - USD ($)", StatementScale{"USD", 1, 1, 1}},
+		{"euros", "Consolidated Balance Sheet - EUR (€) € in Millions", StatementScale{"EUR", 1000000, 1, 1}},
+		{"legacy except per share data", "Consolidated Statements of Income (USD $) In Millions, except Per Share data", StatementScale{"USD", 1000000, 1000000, 1}},
+		{"legacy except share data", "Consolidated Balance Sheets (USD $) In Thousands, except Share data", StatementScale{"USD", 1000, 1, 1}},
+		{"legacy shares in their own scale", "Consolidated Balance Sheets (USD $) In Thousands, except Share data in Millions", StatementScale{"USD", 1000, 1000000, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ParseStatementScale(test.title); got != test.want {
+				t.Errorf("ParseStatementScale(%q) = %+v, want %+v", test.title, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	millions := StatementScale{"USD", 1000000, 1000, 1}
+	unstated := StatementScale{"", 1, 1, 1}
+	tests := []struct {
+		name     string
+		text     string
+		dataType string
+		label    string
+		scale    StatementScale
+		number   string
+		unit     string
+		currency string
+		scaledBy int64
+	}{
+		{"parentheses are negative", "$ (1,234)", "xbrli:monetaryItemType", "Net loss", millions, "-1234000000", UnitMonetary, "USD", 1000000},
+		{"currency symbol without a header currency", "$ 55,256", "xbrli:monetaryItemType", "Net sales", unstated, "55256", UnitMonetary, "USD", 1},
+		{"euro symbol", "€ 12", "xbrli:monetaryItemType", "Revenue", unstated, "12", UnitMonetary, "EUR", 1},
+		{"em dash is zero", "—", "xbrli:monetaryItemType", "Goodwill impairment", millions, "0", UnitMonetary, "USD", 1000000},
+		{"hyphen is zero", "-", "xbrli:monetaryItemType", "Other", millions, "0", UnitMonetary, "USD", 1000000},
+		{"per share keeps its own scale", "$ (2.50)", "num:perShareItemType", "Diluted", millions, "-5/2", UnitPerShare, "USD", 1},
+		{"shares use the share scale", "4,443,236", "xbrli:sharesItemType", "Diluted (in shares)", millions, "4443236000", UnitShares, "", 1000},
+		{"percent", "21.00%", "num:percentItemType", "Effective tax rate", millions, "21/100", UnitPure, "", 1},
+		{"footnote reference", "1,234 [1]", "xbrli:monetaryItemType", "Restructuring", millions, "1234000000", UnitMonetary, "USD", 1000000},
+		{"per share from the label of an untyped row", "$ 3.28", "", "Diluted (in dollars per share)", millions, "82/25", UnitPerShare, "USD", 1},
+		{"empty cell", "", "xbrli:monetaryItemType", "Other", millions, "", UnitMonetary, "", 1},
+		{"text cell", "Yes", "dei:yesNoItemType", "Well-known Seasoned Issuer", millions, "", UnitOther, "", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NormalizeValue(test.text, test.dataType, test.label, test.scale)
+			number := ""
+			if got.Number != nil {
+				number = got.Number.RatString()
+			}
+			if number != test.number || got.Unit != test.unit || got.Currency != test.currency || got.Scale != test.scaledBy {
+				t.Errorf("NormalizeValue(%q) = %s %s %q %d, want %s %s %q %d", test.text, number, got.Unit, got.Currency, got.Scale, test.number, test.unit, test.currency, test.scaledBy)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/iterator"
+)
+
+//submissionsURL is where the SEC serves submissions documents and their pages of older filings
+const submissionsURL = "https://data.sec.gov/submissions/"
+
+//ParseWatchlist reads CIKs separated by commas, spaces or lines, ignoring # comments
+func ParseWatchlist(list string) []string {
+	var ciks []string
+	for _, line := range strings.Split(list, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' }) {
+			ciks = append(ciks, normalizeCIK(field))
+		}
+	}
+	return ciks
+}
+
+//CompanyWorkItems lists the filings to load of a company from its submissions document, reading the pages of older
+//filings with page unless they all predate since. Filings are ordered oldest first so restatements load in order
+func CompanyWorkItems(document SubmissionsDocument, page func(name string) ([]byte, error), since string) ([]FilingWorkItem, error) {
+	cik := document.CIK.String()
+	items := SubmissionsWorkItems(cik, document.Name, document.Filings.Recent, since)
+	for _, file := range document.Filings.Files {
+		if since != "" && file.FilingTo != "" && file.FilingTo < since {
+			continue
+		}
+		data, err := page(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		filings, err := ParseSubmissionsFilings(data)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, SubmissionsWorkItems(cik, document.Name, filings, since)...)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DateFiled < items[j].DateFiled })
+	return items, nil
+}
+
+//loadedFilingsQuery lists which of a set of filings were loaded before. A filing is only recorded in loaded_filings
+//once all of its rows were written, so one a load stopped part way through is loaded again. Filings are matched on
+//their accession number as a Form 4 is filed under both the issuer and the insider
+const loadedFilingsQuery = "SELECT DISTINCT AccessionNumber FROM `%s.SEC.loaded_filings` WHERE AccessionNumber IN UNNEST(@accessionNumbers)"
+
+//watchlistCommand loads the filings of a list of companies from their submissions documents instead of
+//walking the full index, skipping filings that were already loaded
+func watchlistCommand(args []string) {
+	flags := flag.NewFlagSet("watchlist", flag.ExitOnError)
+	cikList := flags.String("ciks", "", "comma separated CIKs to load")
+	watchlistPath := flags.String("file", "", "file of CIKs to load, one per line")
+	submissionsDir := flags.String("submissions", "", "directory of submissions documents to read instead of downloading them from the SEC")
+	since := flags.String("since", "", "only load filings filed on or after this date (YYYY-MM-DD)")
+	reload := flags.Bool("reload", false, "load filings again even if they were loaded before")
+	loaderOptions := addLoaderFlags(flags)
+	flags.Parse(args)
+	statementRules, mappingRules := loaderOptions.rules()
+
+	ciks := ParseWatchlist(*cikList)
+	if *watchlistPath != "" {
+		data, err := os.ReadFile(*watchlistPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ciks = append(ciks, ParseWatchlist(string(data))...)
+	}
+	if len(ciks) == 0 {
+		log.Fatal("pass -ciks or -file")
+	}
+
+	ratelimiter := rate.NewLimiter(10, 10)
+	c := NewClient(ratelimiter)
+	fmt.Println("Please enter in your Google Cloud project name: ")
+	var projectName string
+	fmt.Scanln(&projectName)
+	fmt.Println("Please enter in your user agent in the form of (SampleCompanyName AdminContact@<sample company domain>.com) to use in your request header to the SEC")
+	var userAgent string
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		userAgent = scanner.Text()
+	}
+	//submissions documents are read from disk when a directory is given, otherwise downloaded
+	readSubmissions := func(name string) ([]byte, error) {
+		if *submissionsDir != "" {
+			return os.ReadFile(filepath.Join(*submissionsDir, name))
+		}
+		return GetReportSEC(c, userAgent, submissionsURL+name), nil
+	}
+
+	ctx := context.Background()
+	bq, err := bigquery.NewClient(ctx, projectName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bq.Close()
+	ds := bq.Dataset("SEC")
+	if err := ds.Create(ctx, &bigquery.DatasetMetadata{}); err != nil {
+		fmt.Println(err)
+	}
+	loader := NewFilingLoader(ctx, bq, ds, projectName, c, userAgent, statementRules, mappingRules, *loaderOptions.quarantine)
+
+	//companies share filings, like the Form 4 an insider of one files about another, so they are loaded once
+	var items []FilingWorkItem
+	for _, cik := range ciks {
+		data, err := readSubmissions(SubmissionsFileName(cik))
+		if err != nil {
+			fmt.Println("Can't read submissions of", cik)
+			log.Fatal(err)
+		}
+		document, err := ParseSubmissions(data)
+		if err != nil {
+			fmt.Println("Can't read submissions of", cik)
+			log.Fatal(err)
+		}
+		companyItems, err := CompanyWorkItems(document, readSubmissions, *since)
+		if err != nil {
+			fmt.Println("Can't read older filings of", cik)
+			log.Fatal(err)
+		}
+		fmt.Println(document.Name, len(companyItems), "filings")
+		items = append(items, companyItems...)
+	}
+	items = UniqueWorkItems(items)
+
+	loaded := make(map[string]bool)
+	if !*reload {
+		var accessionNumbers []string
+		for _, item := range items {
+			accessionNumbers = append(accessionNumbers, AccessionNumber(item.FilingLoc))
+		}
+		q := bq.Query(fmt.Sprintf(loadedFilingsQuery, projectName))
+		q.Parameters = []bigquery.QueryParameter{{Name: "accessionNumbers", Value: accessionNumbers}}
+		it, err := q.Read(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for {
+			var row struct{ AccessionNumber string }
+			err := it.Next(&row)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			loaded[row.AccessionNumber] = true
+		}
+	}
+
+	for _, item := range items {
+		if loaded[AccessionNumber(item.FilingLoc)] {
+			continue
+		}
+		loader.Load(item)
+	}
+	loader.Flush()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//FilingWorkItem is a filing to load, however it was found. Year and Quarter are the EDGAR full index
+//the filing is listed in and FilingLoc is its path in the EDGAR archives, e.g. edgar/data/320193/0000320193-20-000010.txt
+type FilingWorkItem struct {
+	Year        string
+	Quarter     string
+	CIK         string
+	CompanyName string
+	Form        string
+	DateFiled   string
+	FilingLoc   string
+}
+
+//FilingDirectoryURL is the folder of the filing's documents in the EDGAR archives
+func (item FilingWorkItem) FilingDirectoryURL() string {
+	return "https://www.sec.gov/Archives/" + strings.Replace(strings.Replace(item.FilingLoc, "-", "", 2), ".txt", "", 1)
+}
+
+//isFinancialStatementForm reports whether a form is loaded, amendments are included as they replace earlier figures
+func isFinancialStatementForm(form string) bool {
+	return form == "10-Q" || form == "10-K" || form == "10-Q/A" || form == "10-K/A"
+}
+
+//isMasterIndexForm reports whether a form without xbrl financial statements is loaded from the master index
+func isMasterIndexForm(form string) bool {
+	return isOwnershipForm(form) || isInstitutionalHoldingsForm(form) || isEventForm(form)
+}
+
+//XBRLIndexWorkItems lists the 10-Q and 10-K filings of an EDGAR full index xbrl.gz file
+func XBRLIndexWorkItems(body []byte, year string, qtr string) []FilingWorkItem {
+	return IndexWorkItems(body, year, qtr, isFinancialStatementForm)
+}
+
+//MasterIndexWorkItems lists the filings of an EDGAR full index master.gz file loaded without xbrl, like Form 4, 13F-HR
+//and 8-K. The master index lists a Form 3, 4 or 5 under the issuer and every reporting owner, each filing is kept once
+func MasterIndexWorkItems(body []byte, year string, qtr string) []FilingWorkItem {
+	return UniqueWorkItems(IndexWorkItems(body, year, qtr, isMasterIndexForm))
+}
+
+//UniqueWorkItems keeps the first work item of every accession number, for filings listed under several CIKs
+func UniqueWorkItems(items []FilingWorkItem) []FilingWorkItem {
+	var unique []FilingWorkItem
+	seen := make(map[string]bool)
+	for _, item := range items {
+		accessionNumber := AccessionNumber(item.FilingLoc)
+		if seen[accessionNumber] {
+			continue
+		}
+		seen[accessionNumber] = true
+		unique = append(unique, item)
+	}
+	return unique
+}
+
+//IndexWorkItems lists the filings of forms accepted in an EDGAR full index file, the xbrl and master indexes share
+//the CIK|Company Name|Form Type|Date Filed|Filename layout
+func IndexWorkItems(body []byte, year string, qtr string, accept func(form string) bool) []FilingWorkItem {
+	pattern := regexp.MustCompile(`---*`)
+	loc := pattern.FindIndex(body)
+	if loc == nil {
+		return nil
+	}
+	r := csv.NewReader(strings.NewReader(string(body)[loc[1]:]))
+	r.Comma = '|'
+	records, _ := r.ReadAll()
+	var items []FilingWorkItem
+	for _, v := range records {
+		if len(v) < 5 || !accept(v[2]) {
+			continue
+		}
+		items = append(items, FilingWorkItem{Year: year, Quarter: qtr, CIK: v[0], CompanyName: v[1], Form: v[2], DateFiled: v[3], FilingLoc: v[4]})
+	}
+	return items
+}
+
+//SubmissionsWorkItems lists the 10-Q and 10-K filings with xbrl and the master index forms in a company's submissions
+//filings, filed on or after since when it is set (YYYY-MM-DD). They are placed in the full index quarter of their
+//filing date, like the index paths
+func SubmissionsWorkItems(cik string, companyName string, filings SubmissionsFilings, since string) []FilingWorkItem {
+	cik = normalizeCIK(cik)
+	var items []FilingWorkItem
+	for i, accessionNumber := range filings.AccessionNumber {
+		if i >= len(filings.Form) || i >= len(filings.FilingDate) {
+			continue
+		}
+		switch form := filings.Form[i]; {
+		case isFinancialStatementForm(form):
+			if i < len(filings.IsXBRL) && filings.IsXBRL[i] != 1 {
+				continue
+			}
+		case !isMasterIndexForm(form):
+			continue
+		}
+		filingDate := filings.FilingDate[i]
+		if len(filingDate) < 7 || filingDate < since {
+			continue
+		}
+		month := 0
+		fmt.Sscanf(filingDate[5:7], "%d", &month)
+		items = append(items, FilingWorkItem{Year: filingDate[:4], Quarter: fmt.Sprintf("QTR%d", (month+2)/3), CIK: cik, CompanyName: companyName, Form: filings.Form[i], DateFiled: filingDate, FilingLoc: "edgar/data/" + cik + "/" + accessionNumber + ".txt"})
+	}
+	return items
+}